@@ -0,0 +1,106 @@
+package auth
+
+import (
+	"database/sql"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+// ActivityTracker buffers the last request of each session, to be written to oauth_sessions
+// in a single batch rather than on every request
+type ActivityTracker struct {
+	mu      sync.Mutex
+	pending map[SessionID]activity
+}
+
+type activity struct {
+	seen      time.Time
+	ip        string
+	userAgent string
+}
+
+// Activity is recorded by ValidateSession
+var Activity = NewActivityTracker()
+
+// NewActivityTracker with nothing pending
+func NewActivityTracker() *ActivityTracker {
+	return &ActivityTracker{pending: map[SessionID]activity{}}
+}
+
+// Record r as the latest request of sessionID
+func (t *ActivityTracker) Record(sessionID SessionID, r *http.Request) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.pending[sessionID] = activity{seen: time.Now(), ip: clientIP(r), userAgent: r.UserAgent()}
+}
+
+// Flush writes the pending activity to oauth_sessions.  On failure it's kept to be retried,
+// unless newer activity for the same session has since been recorded.
+func (t *ActivityTracker) Flush(db *sql.DB) error {
+	t.mu.Lock()
+	pending := t.pending
+	t.pending = map[SessionID]activity{}
+	t.mu.Unlock()
+
+	if len(pending) == 0 {
+		return nil
+	}
+
+	ids := make([]int64, 0, len(pending))
+	seen := make([]string, 0, len(pending))
+	ips := make([]string, 0, len(pending))
+	userAgents := make([]string, 0, len(pending))
+	for id, a := range pending {
+		ids = append(ids, int64(id))
+		seen = append(seen, a.seen.UTC().Format(time.RFC3339Nano))
+		ips = append(ips, a.ip)
+		userAgents = append(userAgents, a.userAgent)
+	}
+
+	// seen is cast from timestamptz, so last_seen_at is stored in the db's time zone like the
+	// created_at written by now()
+	_, err := db.Exec(`
+		update oauth_sessions set
+			last_seen_at = activity.seen,
+			last_ip = activity.ip,
+			last_user_agent = activity.user_agent
+		from unnest($1::bigint[], $2::timestamptz[], $3::varchar[], $4::text[]) as activity(id, seen, ip, user_agent)
+		where oauth_sessions.id = activity.id
+	`, pq.Array(ids), pq.Array(seen), pq.Array(ips), pq.Array(userAgents))
+	if err != nil {
+		t.mu.Lock()
+		for id, a := range pending {
+			if _, newer := t.pending[id]; !newer {
+				t.pending[id] = a
+			}
+		}
+		t.mu.Unlock()
+		return dbError(err, "failed to record session activity")
+	}
+	return nil
+}
+
+// Run flushes every interval until stop is closed, then flushes once more
+func (t *ActivityTracker) Run(db *sql.DB, interval time.Duration, stop <-chan struct{}) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+		case <-stop:
+			err := t.Flush(db)
+			if err != nil {
+				fmt.Println("error flushing session activity: ", err)
+			}
+			return
+		}
+		err := t.Flush(db)
+		if err != nil {
+			fmt.Println("error flushing session activity: ", err)
+		}
+	}
+}
@@ -0,0 +1,51 @@
+package auth
+
+import (
+	"database/sql"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/thematthopkins/impact-go/testdb"
+)
+
+func TestActivityTracker(t *testing.T) {
+	tracker := NewActivityTracker()
+
+	request := httptest.NewRequest("GET", "/", nil)
+	request.RemoteAddr = "10.0.0.1:1234"
+	request.Header.Set("User-Agent", "test")
+	tracker.Record(1, request)
+	tracker.Record(1, request)
+	tracker.Record(2, request)
+
+	assert.Len(t, tracker.pending, 2)
+	assert.Equal(t, "10.0.0.1", tracker.pending[1].ip)
+	assert.Equal(t, "test", tracker.pending[1].userAgent)
+
+	db, err := sql.Open("postgres", "postgres://localhost:1/unavailable?sslmode=disable&connect_timeout=1")
+	assert.NoError(t, err)
+	err = tracker.Flush(db)
+	assert.Equal(t, ErrDBUnavailable, errors.Cause(err))
+	assert.Len(t, tracker.pending, 2, "kept to retry")
+}
+
+func TestActivityTrackerFlushTimeZone(t *testing.T) {
+	db := testdb.Setup()
+	sessionID, err := AddSession(db, "impact.development", 1234)
+	assert.NoError(t, err)
+
+	tracker := NewActivityTracker()
+	tracker.Record(sessionID, httptest.NewRequest("GET", "/", nil))
+	err = tracker.Flush(db)
+	assert.NoError(t, err)
+
+	var createdAt, lastSeenAt time.Time
+	err = db.QueryRow(`
+		select created_at, last_seen_at from oauth_sessions where id = $1
+	`, sessionID).Scan(&createdAt, &lastSeenAt)
+	assert.NoError(t, err)
+	assert.WithinDuration(t, createdAt, lastSeenAt, time.Minute, "in the same time zone")
+}
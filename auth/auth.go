@@ -2,7 +2,7 @@ package auth
 
 import (
 	"database/sql"
-	"github.com/google/uuid"
+	"github.com/lib/pq"
 	"github.com/pkg/errors"
 	"net/http"
 	"strings"
@@ -15,36 +15,127 @@ type UserID int
 // ErrSessionInvalid failure to authenticate user
 var ErrSessionInvalid = errors.New("session invalid")
 
+// ErrSessionExpired is the ErrSessionInvalid of a known access token that has expired.
+// Its Cause is ErrSessionInvalid.
+var ErrSessionExpired error = sessionExpiredError{}
+
+type sessionExpiredError struct{}
+
+func (sessionExpiredError) Error() string {
+	return "session expired"
+}
+
+// Cause for github.com/pkg/errors.Cause
+func (sessionExpiredError) Cause() error {
+	return ErrSessionInvalid
+}
+
+// IsSessionExpired if err was caused by ErrSessionExpired, rather than an unknown access token
+func IsSessionExpired(err error) bool {
+	for err != nil {
+		if err == ErrSessionExpired {
+			return true
+		}
+		causer, ok := err.(interface{ Cause() error })
+		if !ok {
+			return false
+		}
+		err = causer.Cause()
+	}
+	return false
+}
+
 // ErrNoAuthHeader indicates the HTTP Authorization header is missing
 var ErrNoAuthHeader = errors.New("no authorization header")
 
-// Validate user based on http Authorization: Bearer *** token
-func Validate(r *http.Request, db *sql.DB) (UserID, error) {
+// Session is a validated oauth_sessions entry, owned by its Principal
+type Session struct {
+	ID SessionID
+	// AccessTokenID is the oauth_access_tokens.id of the validated token, the token's keyed
+	// hash unless it was stored unhashed
+	AccessTokenID string
+	Principal
+}
+
+// Validate the user or client based on http Authorization: Bearer *** token
+func Validate(r *http.Request, db *sql.DB) (Principal, error) {
+	session, err := ValidateSession(r, db)
+	if err != nil {
+		return Principal{}, err
+	}
+	return session.Principal, nil
+}
+
+// BearerToken from the http Authorization header, if present
+func BearerToken(r *http.Request) (string, bool) {
 	tokens, ok := r.Header["Authorization"]
 	if !ok {
-		return 0, ErrNoAuthHeader
+		return "", false
+	}
+	return strings.TrimPrefix(tokens[0], "Bearer "), true
+}
+
+// ValidateSession finds the Session of the http Authorization: Bearer *** token
+func ValidateSession(r *http.Request, db *sql.DB) (Session, error) {
+	token, ok := BearerToken(r)
+	if !ok {
+		return Session{}, ErrNoAuthHeader
 	}
-	token := strings.TrimPrefix(tokens[0], "Bearer ")
-	now := time.Now().Unix()
-	var userID UserID
+	if session, ok := ValidationCache.Get(token); ok {
+		Activity.Record(session.ID, r)
+		return session, nil
+	}
+
+	var session Session
+	var ownerID string
+	var scopes []string
+	var impersonatorID sql.NullInt64
+	var id string
+	var hashed bool
+	var expireTime int64
 	err := db.QueryRow(`
 		select
-			oauth_sessions.owner_id
+			oauth_sessions.id,
+			oauth_sessions.owner_type,
+			oauth_sessions.owner_id,
+			oauth_sessions.impersonator_id,
+			(select array_agg(scope_id) from oauth_session_scopes where session_id = oauth_sessions.id),
+			oauth_access_tokens.id,
+			oauth_access_tokens.hashed,
+			oauth_access_tokens.expire_time
 		from 
 			oauth_access_tokens
 			join oauth_sessions on oauth_access_tokens.session_id = oauth_sessions.id
 		where
-			oauth_access_tokens.id = $1
-			and oauth_access_tokens.expire_time > $2
-		`, token, now).Scan(&userID)
+			`+tokenMatch("oauth_access_tokens")+`
+		`, tokenArgs(token)...).Scan(&session.ID, &session.OwnerType, &ownerID, &impersonatorID, pq.Array(&scopes), &id, &hashed, &expireTime)
 
 	if err == sql.ErrNoRows {
-		return 0, errors.Wrapf(ErrSessionInvalid, token)
+		return Session{}, errors.Wrap(ErrSessionInvalid, "no access token matches")
 	} else if err != nil {
-		panic(err)
+		return Session{}, dbError(err, "failed to validate session")
 	}
 
-	return userID, nil
+	if !storedTokenMatches(id, hashed, token) {
+		return Session{}, errors.Wrap(ErrSessionInvalid, "access token doesn't match its stored hash")
+	}
+	if expireTime <= time.Now().Unix() {
+		return Session{}, errors.Wrapf(ErrSessionExpired, "access token of session %d", session.ID)
+	}
+
+	err = session.setOwner(ownerID)
+	if err != nil {
+		return Session{}, err
+	}
+	session.AccessTokenID = id
+	session.ImpersonatorID = UserID(impersonatorID.Int64)
+	for _, scope := range scopes {
+		session.Scopes = append(session.Scopes, Scope(scope))
+	}
+
+	ValidationCache.Add(token, session, time.Unix(expireTime, 0))
+	Activity.Record(session.ID, r)
+	return session, nil
 }
 
 // ClientName represents oauth_clients.name entry in the db
@@ -55,29 +146,64 @@ type SessionID int64
 
 // AddSession creates a new oauth session for ClientName
 func AddSession(db *sql.DB, oauthClientName ClientName, userID UserID) (SessionID, error) {
+	return addSession(db, oauthClientName, userID)
+}
+
+// AddSessionWithToken creates a new oauth session for ClientName along with its first
+// AccessToken and RefreshToken, all or nothing
+func AddSessionWithToken(db *sql.DB, oauthClientName ClientName, userID UserID, accessTokenExpiration time.Time, refreshTokenExpiration time.Time) (SessionID, AccessToken, RefreshToken, error) {
+	err := checkExpirations(accessTokenExpiration, refreshTokenExpiration)
+	if err != nil {
+		return 0, AccessToken{}, RefreshToken{}, err
+	}
+
+	tx, err := db.Begin()
+	if err != nil {
+		return 0, AccessToken{}, RefreshToken{}, dbError(err, "failed to begin transaction")
+	}
+	defer tx.Rollback()
+
+	sessionID, err := addSession(tx, oauthClientName, userID)
+	if err != nil {
+		return 0, AccessToken{}, RefreshToken{}, err
+	}
+
+	accessToken, refreshToken, err := addSessionToken(tx, sessionID, accessTokenExpiration, refreshTokenExpiration)
+	if err != nil {
+		return 0, AccessToken{}, RefreshToken{}, err
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		return 0, AccessToken{}, RefreshToken{}, dbError(err, "failed to commit session")
+	}
+
+	return sessionID, accessToken, refreshToken, nil
+}
+
+func addSession(db execer, oauthClientName ClientName, userID UserID) (SessionID, error) {
 	var clientID string
 	err := db.QueryRow(`
 		select id from oauth_clients where name = $1
 	`, oauthClientName).Scan(&clientID)
 	if err == sql.ErrNoRows {
-		return 0, errors.Errorf("failed to find oauth client: %s", oauthClientName)
+		return 0, errors.Wrapf(ErrNotFound, "failed to find oauth client: %s", oauthClientName)
 	} else if err != nil {
-		panic(err)
+		return 0, dbError(err, "failed to find oauth client")
 	}
 
 	var sessionID SessionID
-	
+
 	err = db.QueryRow(`
 		insert into oauth_sessions(client_id, owner_type, owner_id, created_at, updated_at) values ($1, 'user', $2, now(), now()) returning id
 	`, clientID, userID).Scan(&sessionID)
 
 	if err != nil {
-		panic(err)
+		return 0, dbError(err, "failed to add session")
 	}
 	return sessionID, nil
 }
 
-
 // AccessToken is associated with a SessionID and gets supplied in the Authorization http header for authentication
 type AccessToken struct {
 	Token      string
@@ -92,27 +218,84 @@ type RefreshToken struct {
 
 // AddSessionToken adds a new AccessToken and RefreshToken associated with the SessionID
 func AddSessionToken(db *sql.DB, sessionID SessionID, accessTokenExpiration time.Time, refreshTokenExpiration time.Time) (AccessToken, RefreshToken, error) {
-	accessToken := AccessToken{
-		Token:      uuid.New().String(),
-		Expiration: accessTokenExpiration,
+	err := checkExpirations(accessTokenExpiration, refreshTokenExpiration)
+	if err != nil {
+		return AccessToken{}, RefreshToken{}, err
 	}
-	_, err := db.Exec(`
-		insert into oauth_access_tokens(id, session_id, expire_time, created_at, updated_at) values($1, $2, $3, now(), now())
-	`, accessToken.Token, sessionID, accessTokenExpiration.Unix())
+
+	tx, err := db.Begin()
 	if err != nil {
-		panic(err)
+		return AccessToken{}, RefreshToken{}, dbError(err, "failed to begin transaction")
 	}
+	defer tx.Rollback()
 
+	accessToken, refreshToken, err := addSessionToken(tx, sessionID, accessTokenExpiration, refreshTokenExpiration)
+	if err != nil {
+		return AccessToken{}, RefreshToken{}, err
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		return AccessToken{}, RefreshToken{}, dbError(err, "failed to commit session token")
+	}
+
+	return accessToken, refreshToken, nil
+}
+
+// execer is satisfied by both *sql.DB and *sql.Tx
+type execer interface {
+	Exec(query string, args ...interface{}) (sql.Result, error)
+	QueryRow(query string, args ...interface{}) *sql.Row
+}
+
+func checkExpirations(accessTokenExpiration time.Time, refreshTokenExpiration time.Time) error {
+	if refreshTokenExpiration.Before(accessTokenExpiration) {
+		return errors.Wrap(ErrInvalidInput, "refresh token expires before its access token")
+	}
+	return nil
+}
+
+func addSessionToken(db execer, sessionID SessionID, accessTokenExpiration time.Time, refreshTokenExpiration time.Time) (AccessToken, RefreshToken, error) {
+	accessToken, accessTokenID, err := addAccessToken(db, sessionID, accessTokenExpiration)
+	if err != nil {
+		return AccessToken{}, RefreshToken{}, err
+	}
+
+	refreshTokenValue, err := newToken()
+	if err != nil {
+		return AccessToken{}, RefreshToken{}, errors.Wrap(err, "failed to generate refresh token")
+	}
 	refreshToken := RefreshToken{
-		Token:      uuid.New().String(),
+		Token:      refreshTokenValue,
 		Expiration: refreshTokenExpiration,
 	}
 	_, err = db.Exec(`
-		insert into oauth_refresh_tokens(id, access_token_id, expire_time, created_at, updated_at) values($1, $2, $3, now(), now())
-	`, refreshToken.Token, accessToken.Token, refreshTokenExpiration.Unix())
+		insert into oauth_refresh_tokens(id, hashed, access_token_id, expire_time, created_at, updated_at) values($1, true, $2, $3, now(), now())
+	`, hashToken(refreshToken.Token), accessTokenID, refreshTokenExpiration.Unix())
 	if err != nil {
-		panic(err)
+		return AccessToken{}, RefreshToken{}, dbError(err, "failed to add refresh token")
 	}
 
 	return accessToken, refreshToken, nil
 }
+
+// addAccessToken returns the new AccessToken along with the id it's stored under
+func addAccessToken(db execer, sessionID SessionID, accessTokenExpiration time.Time) (AccessToken, string, error) {
+	accessTokenValue, err := newToken()
+	if err != nil {
+		return AccessToken{}, "", errors.Wrap(err, "failed to generate access token")
+	}
+	accessToken := AccessToken{
+		Token:      accessTokenValue,
+		Expiration: accessTokenExpiration,
+	}
+	accessTokenID := hashToken(accessToken.Token)
+	_, err = db.Exec(`
+		insert into oauth_access_tokens(id, hashed, session_id, expire_time, created_at, updated_at) values($1, true, $2, $3, now(), now())
+	`, accessTokenID, sessionID, accessTokenExpiration.Unix())
+	if err != nil {
+		return AccessToken{}, "", dbError(err, "failed to add access token")
+	}
+
+	return accessToken, accessTokenID, nil
+}
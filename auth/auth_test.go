@@ -19,7 +19,7 @@ func addAccessToken(db *sql.DB, userID auth.UserID, expiration time.Time) (auth.
 		return auth.AccessToken{}, err
 	}
 
-	refreshTokenExpiration := time.Now().Add(time.Second * 10)
+	refreshTokenExpiration := expiration.Add(time.Second * 10)
 	accessToken, _, err := auth.AddSessionToken(db, sessionID, expiration, refreshTokenExpiration)
 	if err != nil {
 		return auth.AccessToken{}, err
@@ -42,10 +42,29 @@ func TestValid(t *testing.T) {
 	var request = httptest.NewRequest("GET", "/", nil)
 	request.Header.Add("Authorization", fmt.Sprintf("Bearer %v", accessToken.Token))
 
-	userID, err := auth.Validate(request, db)
+	principal, err := auth.Validate(request, db)
 
 	assert.NoError(t, err)
-	assert.True(t, userID == 1234, "user id: ", userID)
+	assert.True(t, principal.IsUser())
+	assert.True(t, principal.UserID == 1234, "user id: ", principal.UserID)
+}
+
+func TestValidSessionAccessTokenID(t *testing.T) {
+	db := testdb.Setup()
+	accessToken, err := addAccessToken(db, 1234, time.Now().Add(time.Second))
+	assert.NoError(t, err)
+
+	var request = httptest.NewRequest("GET", "/", nil)
+	request.Header.Add("Authorization", fmt.Sprintf("Bearer %v", accessToken.Token))
+
+	session, err := auth.ValidateSession(request, db)
+	assert.NoError(t, err)
+
+	var stored string
+	err = db.QueryRow(`select id from oauth_access_tokens where session_id = $1`, session.ID).Scan(&stored)
+	assert.NoError(t, err)
+	assert.Equal(t, stored, session.AccessTokenID)
+	assert.NotEqual(t, accessToken.Token, session.AccessTokenID)
 }
 
 func TestExpired(t *testing.T) {
@@ -58,6 +77,14 @@ func TestExpired(t *testing.T) {
 
 	_, err = auth.Validate(request, db)
 	assert.True(t, errors.Cause(err) == auth.ErrSessionInvalid, "err: ", err)
+	assert.True(t, auth.IsSessionExpired(err), "err: ", err)
+}
+
+func TestIsSessionExpired(t *testing.T) {
+	assert.True(t, auth.IsSessionExpired(errors.Wrap(auth.ErrSessionExpired, "token")))
+	assert.Equal(t, auth.ErrSessionInvalid, errors.Cause(errors.Wrap(auth.ErrSessionExpired, "token")))
+	assert.False(t, auth.IsSessionExpired(errors.Wrap(auth.ErrSessionInvalid, "token")))
+	assert.False(t, auth.IsSessionExpired(nil))
 }
 
 func TestInvalid(t *testing.T) {
@@ -66,6 +93,8 @@ func TestInvalid(t *testing.T) {
 	request.Header.Add("Authorization", "Bearer invalidToken")
 	_, err := auth.Validate(request, db)
 	assert.EqualError(t, errors.Cause(err), auth.ErrSessionInvalid.Error())
+	assert.NotContains(t, err.Error(), "invalidToken")
+	assert.False(t, auth.IsSessionExpired(err))
 }
 
 func TestMissingAuthHeader(t *testing.T) {
@@ -74,3 +103,66 @@ func TestMissingAuthHeader(t *testing.T) {
 	_, err := auth.Validate(request, db)
 	assert.EqualError(t, errors.Cause(err), auth.ErrNoAuthHeader.Error())
 }
+
+func TestAddSessionTokenInvalidExpiration(t *testing.T) {
+	db := testdb.Setup()
+	_, _, err := auth.AddSessionToken(db, 1, time.Now().Add(time.Minute), time.Now())
+	assert.Equal(t, auth.ErrInvalidInput, errors.Cause(err))
+}
+
+func TestDBUnavailable(t *testing.T) {
+	db, err := sql.Open("postgres", "postgres://localhost:1/unavailable?sslmode=disable&connect_timeout=1")
+	assert.NoError(t, err)
+
+	_, err = auth.AddSession(db, "impact.development", 1234)
+	assert.Equal(t, auth.ErrDBUnavailable, errors.Cause(err))
+
+	var request = httptest.NewRequest("GET", "/", nil)
+	request.Header.Add("Authorization", "Bearer unknown")
+	_, err = auth.Validate(request, db)
+	assert.Equal(t, auth.ErrDBUnavailable, errors.Cause(err))
+}
+
+func TestSessionWithToken(t *testing.T) {
+	db := testdb.Setup()
+	sessionID, accessToken, _, err := auth.AddSessionWithToken(db, "impact.development", 1234, time.Now().Add(time.Minute), time.Now().Add(time.Hour))
+	assert.NoError(t, err)
+
+	assert.NotZero(t, sessionID)
+	userID, err := validateToken(db, accessToken.Token)
+	assert.NoError(t, err)
+	assert.Equal(t, auth.UserID(1234), userID)
+
+	_, _, _, err = auth.AddSessionWithToken(db, "invalidClientID", 1234, time.Now().Add(time.Minute), time.Now().Add(time.Hour))
+	assert.Equal(t, auth.ErrNotFound, errors.Cause(err))
+}
+
+func TestTokensHashedAtRest(t *testing.T) {
+	db := testdb.Setup()
+	accessToken, err := addAccessToken(db, 1234, time.Now().Add(time.Minute))
+	assert.NoError(t, err)
+
+	var count int
+	err = db.QueryRow(`select count(*) from oauth_access_tokens where id = $1`, accessToken.Token).Scan(&count)
+	assert.NoError(t, err)
+	assert.Equal(t, 0, count)
+
+	_, err = validateToken(db, accessToken.Token)
+	assert.NoError(t, err)
+}
+
+func TestLegacyToken(t *testing.T) {
+	db := testdb.Setup()
+	sessionID, err := auth.AddSession(db, "impact.development", 1234)
+	assert.NoError(t, err)
+
+	legacyToken := "0123456789abcdef0123456789abcdef01234567"
+	_, err = db.Exec(`
+		insert into oauth_access_tokens(id, session_id, expire_time, created_at, updated_at) values($1, $2, $3, now(), now())
+	`, legacyToken, sessionID, time.Now().Add(time.Minute).Unix())
+	assert.NoError(t, err)
+
+	userID, err := validateToken(db, legacyToken)
+	assert.NoError(t, err)
+	assert.Equal(t, auth.UserID(1234), userID)
+}
@@ -0,0 +1,158 @@
+package auth
+
+import (
+	"database/sql"
+	"sort"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// AssessmentID is the id of the assessment
+type AssessmentID int64
+
+// ErrForbidden the user lacks permission for the action on the assessment
+var ErrForbidden = errors.New("forbidden")
+
+// Role of a user relative to an assessment
+type Role string
+
+// Roles a user may hold for an assessment
+const (
+	// RoleOwner has an owner account_access entry on the assessment's account
+	RoleOwner Role = "owner"
+	// RoleCollaborator has any other account_access entry on the assessment's account
+	RoleCollaborator Role = "collaborator"
+	// RoleVerifier is the assessment's assigned verifier
+	RoleVerifier Role = "verifier"
+	// RoleStaff is a staff user, independent of the assessment
+	RoleStaff Role = "staff"
+)
+
+// Action performed on an assessment
+type Action string
+
+// Actions which may be permitted on an assessment
+const (
+	ViewAssessment   Action = "view"
+	EditAssessment   Action = "edit"
+	SubmitAssessment Action = "submit"
+	VerifyAssessment Action = "verify"
+	ExportReport     Action = "export"
+	ManageAccess     Action = "manage"
+)
+
+// ActionScope is the scope granting a client action on every assessment, e.g.
+// internal:assessment:export.  Being internal, only our own clients may be granted it.
+func ActionScope(action Action) Scope {
+	return Scope(internalScopePrefix + "assessment:" + string(action))
+}
+
+var rolePermissions = map[Role][]Action{
+	RoleOwner:        {ViewAssessment, EditAssessment, SubmitAssessment, ExportReport, ManageAccess},
+	RoleCollaborator: {ViewAssessment, EditAssessment},
+	RoleVerifier:     {ViewAssessment, VerifyAssessment, ExportReport},
+	RoleStaff:        {ViewAssessment, EditAssessment, SubmitAssessment, VerifyAssessment, ExportReport, ManageAccess},
+}
+
+// Permissions is the set of actions allowed on an assessment
+type Permissions map[Action]struct{}
+
+// PermissionsFor the union of the actions allowed by each role
+func PermissionsFor(roles []Role) Permissions {
+	result := Permissions{}
+	for _, role := range roles {
+		for _, action := range rolePermissions[role] {
+			result[action] = struct{}{}
+		}
+	}
+	return result
+}
+
+// Allows reports if action is permitted
+func (p Permissions) Allows(action Action) bool {
+	_, ok := p[action]
+	return ok
+}
+
+// String is the comma separated, sorted list of actions, as sent in the assessment's permissions field
+func (p Permissions) String() string {
+	actions := make([]string, 0, len(p))
+	for action := range p {
+		actions = append(actions, string(action))
+	}
+	sort.Strings(actions)
+	return strings.Join(actions, ",")
+}
+
+// AssessmentRoles finds the roles userID holds for assessmentID, through staff status,
+// account membership or verifier assignment
+func AssessmentRoles(db *sql.DB, userID UserID, assessmentID AssessmentID) ([]Role, error) {
+	rows, err := db.Query(`
+		select 'staff'
+		from impact_user
+		where impact_user.id = $1 and impact_user.user_type = 'staff'
+		union
+		select case when account_access.role = 'owner' then 'owner' else 'collaborator' end
+		from
+			assessment
+			join account_access on account_access.account_id = assessment.account_id
+		where account_access.user_id = $1 and assessment.id = $2
+		union
+		select 'verifier'
+		from assessment
+		where assessment.id = $2 and assessment.verifier_id = $1
+	`, userID, assessmentID)
+	if err != nil {
+		return nil, dbError(err, "failed to find assessment roles")
+	}
+	defer rows.Close()
+
+	roles := []Role{}
+	for rows.Next() {
+		var role Role
+		err = rows.Scan(&role)
+		if err != nil {
+			return nil, dbError(err, "failed to find assessment roles")
+		}
+		roles = append(roles, role)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, dbError(err, "failed to find assessment roles")
+	}
+
+	sort.Slice(roles, func(i, j int) bool { return roles[i] < roles[j] })
+	return roles, nil
+}
+
+// AssessmentPermissions finds the actions userID may perform on assessmentID
+func AssessmentPermissions(db *sql.DB, userID UserID, assessmentID AssessmentID) (Permissions, error) {
+	roles, err := AssessmentRoles(db, userID, assessmentID)
+	if err != nil {
+		return Permissions{}, err
+	}
+	return PermissionsFor(roles), nil
+}
+
+// Authorize returns ErrForbidden unless the principal's user may perform action on assessmentID,
+// or for a client, unless it was granted the action's ActionScope, which only internal clients are
+func Authorize(db *sql.DB, principal Principal, assessmentID AssessmentID, action Action) error {
+	if !principal.IsUser() {
+		if !principal.HasScope(ActionScope(action)) {
+			return errors.Wrapf(ErrForbidden, "client %s may not %s assessment %d", principal.ClientID, action, assessmentID)
+		}
+		return nil
+	}
+	if !principal.Permits(action) {
+		return errors.Wrapf(ErrForbidden, "staff %d may not %s assessment %d while impersonating", principal.ImpersonatorID, action, assessmentID)
+	}
+
+	permissions, err := AssessmentPermissions(db, principal.UserID, assessmentID)
+	if err != nil {
+		return err
+	}
+	if !permissions.Allows(action) {
+		return errors.Wrapf(ErrForbidden, "user %d may not %s assessment %d", principal.UserID, action, assessmentID)
+	}
+	return nil
+}
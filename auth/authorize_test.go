@@ -0,0 +1,52 @@
+package auth_test
+
+import (
+	"testing"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/thematthopkins/impact-go/auth"
+	"github.com/thematthopkins/impact-go/testdb"
+)
+
+func TestPermissionsFor(t *testing.T) {
+	assert.Equal(t, "", auth.PermissionsFor([]auth.Role{}).String())
+	assert.Equal(t, "edit,view", auth.PermissionsFor([]auth.Role{auth.RoleCollaborator}).String())
+	assert.Equal(t, "edit,export,verify,view", auth.PermissionsFor([]auth.Role{auth.RoleCollaborator, auth.RoleVerifier}).String())
+
+	owner := auth.PermissionsFor([]auth.Role{auth.RoleOwner})
+	assert.True(t, owner.Allows(auth.ManageAccess))
+	assert.False(t, owner.Allows(auth.VerifyAssessment))
+}
+
+func TestAuthorizeNoRoles(t *testing.T) {
+	db := testdb.Setup()
+	err := auth.Authorize(db, auth.Principal{OwnerType: auth.UserOwner, UserID: 1234}, -1, auth.ViewAssessment)
+	assert.Equal(t, auth.ErrForbidden, errors.Cause(err))
+}
+
+func TestAuthorizeRestricted(t *testing.T) {
+	db := testdb.Setup()
+
+	err := auth.Authorize(db, auth.Principal{OwnerType: auth.ClientOwner, ClientID: "partner"}, 1, auth.ViewAssessment)
+	assert.Equal(t, auth.ErrForbidden, errors.Cause(err))
+
+	err = auth.Authorize(db, auth.Principal{OwnerType: auth.ClientOwner, ClientID: "partner", Scopes: []auth.Scope{"internal:assessment:export"}}, 1, auth.ViewAssessment)
+	assert.Equal(t, auth.ErrForbidden, errors.Cause(err))
+
+	err = auth.Authorize(db, auth.Principal{OwnerType: auth.ClientOwner, ClientID: "partner", Scopes: []auth.Scope{"assessment:export"}}, 1, auth.ExportReport)
+	assert.Equal(t, auth.ErrForbidden, errors.Cause(err))
+
+	err = auth.Authorize(db, auth.Principal{OwnerType: auth.UserOwner, UserID: 1234, ImpersonatorID: 1}, 1, auth.SubmitAssessment)
+	assert.Equal(t, auth.ErrForbidden, errors.Cause(err))
+}
+
+func TestAuthorizeClientScope(t *testing.T) {
+	db := testdb.Setup()
+	principal := auth.Principal{OwnerType: auth.ClientOwner, ClientID: "jobs", Scopes: []auth.Scope{auth.ActionScope(auth.ExportReport)}}
+
+	err := auth.Authorize(db, principal, 1, auth.ExportReport)
+	assert.NoError(t, err)
+	assert.Equal(t, auth.Scope("internal:assessment:export"), auth.ActionScope(auth.ExportReport))
+	assert.True(t, auth.ActionScope(auth.ExportReport).Internal())
+}
@@ -0,0 +1,192 @@
+package auth
+
+import (
+	"container/list"
+	"crypto/sha256"
+	"fmt"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+// revocationChannel is notified with the session_id of every deleted access token
+const revocationChannel = "auth_revocations"
+
+// TokenCache is a bounded, least recently used cache of validated access tokens.
+// Entries live for at most the cache's ttl, and never past the token's expiration.
+// A cache with no ttl caches nothing.
+type TokenCache struct {
+	mu         sync.Mutex
+	maxEntries int
+	ttl        time.Duration
+	now        func() time.Time
+	entries    map[[sha256.Size]byte]*list.Element
+	order      *list.List
+	stats      CacheStats
+}
+
+// CacheStats counts TokenCache activity since creation
+type CacheStats struct {
+	Hits      uint64
+	Misses    uint64
+	Evictions uint64
+	Entries   int
+}
+
+type cacheEntry struct {
+	key     [sha256.Size]byte
+	session Session
+	expires time.Time
+}
+
+// ValidationCache is consulted by ValidateSession before querying the db, and
+// cleared of revoked sessions by the Revoke functions and ListenForRevocations
+var ValidationCache = NewTokenCache(10000, time.Minute)
+
+// NewTokenCache holds up to maxEntries tokens, each for at most ttl
+func NewTokenCache(maxEntries int, ttl time.Duration) *TokenCache {
+	return &TokenCache{
+		maxEntries: maxEntries,
+		ttl:        ttl,
+		now:        time.Now,
+		entries:    map[[sha256.Size]byte]*list.Element{},
+		order:      list.New(),
+	}
+}
+
+// Get the Session of token, if cached and not yet expired
+func (c *TokenCache) Get(token string) (Session, bool) {
+	key := sha256.Sum256([]byte(token))
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	element, ok := c.entries[key]
+	if !ok {
+		c.stats.Misses++
+		return Session{}, false
+	}
+
+	entry := element.Value.(*cacheEntry)
+	if !c.now().Before(entry.expires) {
+		c.removeElement(element)
+		c.stats.Misses++
+		return Session{}, false
+	}
+
+	c.order.MoveToFront(element)
+	c.stats.Hits++
+	return entry.session, true
+}
+
+// Add token's Session, evicting the least recently used token when full
+func (c *TokenCache) Add(token string, session Session, expiration time.Time) {
+	if c.ttl <= 0 {
+		return
+	}
+	key := sha256.Sum256([]byte(token))
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	expires := c.now().Add(c.ttl)
+	if expiration.Before(expires) {
+		expires = expiration
+	}
+
+	if element, ok := c.entries[key]; ok {
+		c.removeElement(element)
+	}
+
+	c.entries[key] = c.order.PushFront(&cacheEntry{key: key, session: session, expires: expires})
+
+	for c.order.Len() > c.maxEntries {
+		c.removeElement(c.order.Back())
+		c.stats.Evictions++
+	}
+}
+
+// RemoveSession drops every token of sessionID
+func (c *TokenCache) RemoveSession(sessionID SessionID) {
+	c.removeWhere(func(session Session) bool { return session.ID == sessionID })
+}
+
+// RemoveUser drops every token of userID
+func (c *TokenCache) RemoveUser(userID UserID) {
+	c.removeWhere(func(session Session) bool { return session.IsUser() && session.UserID == userID })
+}
+
+// Clear drops every token
+func (c *TokenCache) Clear() {
+	c.removeWhere(func(session Session) bool { return true })
+}
+
+// ListenForRevocations drops the sessions of access tokens deleted by any instance, or by the
+// PHP api, until stop is closed.  Notifications are missed while the connection is down, so
+// every token is dropped once it's reestablished.
+func (c *TokenCache) ListenForRevocations(databaseURL string, stop <-chan struct{}) error {
+	listener := pq.NewListener(databaseURL, time.Second, time.Minute, func(event pq.ListenerEventType, err error) {
+		if err != nil {
+			fmt.Println("error listening for revocations: ", err)
+		}
+	})
+	defer listener.Close()
+
+	err := listener.Listen(revocationChannel)
+	if err != nil {
+		return dbError(err, "failed to listen for revocations")
+	}
+
+	for {
+		select {
+		case notification := <-listener.Notify:
+			c.revoked(notification)
+		case <-stop:
+			return nil
+		}
+	}
+}
+
+// revoked drops the session of notification, or every token when notifications may have been missed
+func (c *TokenCache) revoked(notification *pq.Notification) {
+	if notification == nil {
+		c.Clear()
+		return
+	}
+	sessionID, err := strconv.ParseInt(notification.Extra, 10, 64)
+	if err != nil {
+		c.Clear()
+		return
+	}
+	c.RemoveSession(SessionID(sessionID))
+}
+
+// Stats as of now
+func (c *TokenCache) Stats() CacheStats {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	stats := c.stats
+	stats.Entries = c.order.Len()
+	return stats
+}
+
+func (c *TokenCache) removeWhere(matches func(Session) bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for element := c.order.Front(); element != nil; {
+		next := element.Next()
+		if matches(element.Value.(*cacheEntry).session) {
+			c.removeElement(element)
+		}
+		element = next
+	}
+}
+
+func (c *TokenCache) removeElement(element *list.Element) {
+	c.order.Remove(element)
+	delete(c.entries, element.Value.(*cacheEntry).key)
+}
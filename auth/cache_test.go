@@ -0,0 +1,89 @@
+package auth
+
+import (
+	"testing"
+	"time"
+
+	"github.com/lib/pq"
+	"github.com/stretchr/testify/assert"
+)
+
+func testCache(maxEntries int, ttl time.Duration) (*TokenCache, *time.Time) {
+	now := time.Unix(1000, 0)
+	cache := NewTokenCache(maxEntries, ttl)
+	cache.now = func() time.Time { return now }
+	return cache, &now
+}
+
+func TestTokenCacheExpiry(t *testing.T) {
+	cache, now := testCache(10, time.Minute)
+	cache.Add("a", Session{ID: 1, Principal: Principal{OwnerType: UserOwner, UserID: 10}}, now.Add(time.Hour))
+	cache.Add("b", Session{ID: 2, Principal: Principal{OwnerType: UserOwner, UserID: 10}}, now.Add(time.Second))
+
+	session, ok := cache.Get("a")
+	assert.True(t, ok)
+	assert.Equal(t, Session{ID: 1, Principal: Principal{OwnerType: UserOwner, UserID: 10}}, session)
+
+	*now = now.Add(time.Second)
+	_, ok = cache.Get("b")
+	assert.False(t, ok, "capped at the token's expiration")
+
+	*now = now.Add(time.Minute)
+	_, ok = cache.Get("a")
+	assert.False(t, ok, "capped at the ttl")
+
+	assert.Equal(t, CacheStats{Hits: 1, Misses: 2}, cache.Stats())
+}
+
+func TestTokenCacheEviction(t *testing.T) {
+	cache, now := testCache(2, time.Minute)
+	cache.Add("a", Session{ID: 1}, now.Add(time.Hour))
+	cache.Add("b", Session{ID: 2}, now.Add(time.Hour))
+	cache.Get("a")
+	cache.Add("c", Session{ID: 3}, now.Add(time.Hour))
+
+	_, ok := cache.Get("b")
+	assert.False(t, ok)
+	_, ok = cache.Get("a")
+	assert.True(t, ok)
+	assert.Equal(t, CacheStats{Hits: 2, Misses: 1, Evictions: 1, Entries: 2}, cache.Stats())
+}
+
+func TestTokenCacheRemove(t *testing.T) {
+	cache, now := testCache(10, time.Minute)
+	cache.Add("a", Session{ID: 1, Principal: Principal{OwnerType: UserOwner, UserID: 10}}, now.Add(time.Hour))
+	cache.Add("b", Session{ID: 2, Principal: Principal{OwnerType: UserOwner, UserID: 10}}, now.Add(time.Hour))
+	cache.Add("c", Session{ID: 3, Principal: Principal{OwnerType: UserOwner, UserID: 11}}, now.Add(time.Hour))
+
+	cache.RemoveSession(3)
+	_, ok := cache.Get("c")
+	assert.False(t, ok)
+
+	cache.RemoveUser(10)
+	assert.Equal(t, 0, cache.Stats().Entries)
+}
+
+func TestTokenCacheRevoked(t *testing.T) {
+	cache, now := testCache(10, time.Minute)
+	cache.Add("a", Session{ID: 1}, now.Add(time.Hour))
+	cache.Add("b", Session{ID: 2}, now.Add(time.Hour))
+	cache.Add("c", Session{ID: 3}, now.Add(time.Hour))
+
+	cache.revoked(&pq.Notification{Channel: revocationChannel, Extra: "2"})
+	_, ok := cache.Get("b")
+	assert.False(t, ok)
+	_, ok = cache.Get("a")
+	assert.True(t, ok)
+
+	cache.revoked(nil)
+	assert.Equal(t, 0, cache.Stats().Entries, "reconnecting drops every token")
+}
+
+func TestTokenCacheDisabled(t *testing.T) {
+	cache, now := testCache(10, 0)
+	cache.Add("a", Session{ID: 1}, now.Add(time.Hour))
+
+	_, ok := cache.Get("a")
+	assert.False(t, ok)
+	assert.Equal(t, CacheStats{Misses: 1}, cache.Stats())
+}
@@ -0,0 +1,182 @@
+package auth
+
+import (
+	"crypto/subtle"
+	"database/sql"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// ClientID is the oauth_clients.id of an api client
+type ClientID string
+
+// Scope is an oauth_scopes.id limiting what a session may access
+type Scope string
+
+// internalScopePrefix starts the scopes only internal clients, rather than partners, may be granted
+const internalScopePrefix = "internal:"
+
+// Internal if only internal clients may be granted the scope
+func (s Scope) Internal() bool {
+	return strings.HasPrefix(string(s), internalScopePrefix)
+}
+
+// OwnerType is the oauth_sessions.owner_type
+type OwnerType string
+
+// Owners of an oauth session
+const (
+	UserOwner   OwnerType = "user"
+	ClientOwner OwnerType = "client"
+)
+
+// ErrClientInvalid the client doesn't exist or its secret doesn't match
+var ErrClientInvalid = errors.New("client invalid")
+
+// ErrScopeInvalid a requested scope isn't granted to the client, or is internal and the client isn't
+var ErrScopeInvalid = errors.New("scope invalid")
+
+// Principal is the user, or for machine to machine access the client, a session acts as
+type Principal struct {
+	OwnerType OwnerType
+	UserID    UserID
+	ClientID  ClientID
+	Scopes    []Scope
+	// ImpersonatorID is the staff member acting as UserID, if any
+	ImpersonatorID UserID
+}
+
+// IsUser if the principal is a user rather than a client
+func (p Principal) IsUser() bool {
+	return p.OwnerType == UserOwner
+}
+
+// HasScope if the session was granted scope
+func (p Principal) HasScope(scope Scope) bool {
+	return hasScope(p.Scopes, scope)
+}
+
+func hasScope(scopes []Scope, scope Scope) bool {
+	for _, s := range scopes {
+		if s == scope {
+			return true
+		}
+	}
+	return false
+}
+
+func (p *Principal) setOwner(ownerID string) error {
+	switch p.OwnerType {
+	case UserOwner:
+		userID, err := strconv.Atoi(ownerID)
+		if err != nil {
+			return errors.Wrapf(ErrSessionInvalid, "invalid user owner %s", ownerID)
+		}
+		p.UserID = UserID(userID)
+	case ClientOwner:
+		p.ClientID = ClientID(ownerID)
+	default:
+		return errors.Wrapf(ErrSessionInvalid, "unknown owner type %s", p.OwnerType)
+	}
+	return nil
+}
+
+// AddClientSession authenticates the client by its secret and creates a session it owns,
+// granted the requested scopes or, when none are requested, all of the client's scopes.
+// Only an AccessToken is issued; clients request a new one once it expires.
+func AddClientSession(db *sql.DB, clientID ClientID, secret string, scopes []Scope, accessTokenExpiration time.Time) (Principal, AccessToken, error) {
+	tx, err := db.Begin()
+	if err != nil {
+		return Principal{}, AccessToken{}, dbError(err, "failed to begin transaction")
+	}
+	defer tx.Rollback()
+
+	var storedSecret string
+	var internal bool
+	err = tx.QueryRow(`
+		select secret, internal from oauth_clients where id = $1
+	`, clientID).Scan(&storedSecret, &internal)
+	if err == sql.ErrNoRows {
+		return Principal{}, AccessToken{}, errors.Wrapf(ErrClientInvalid, "unknown client %s", clientID)
+	} else if err != nil {
+		return Principal{}, AccessToken{}, dbError(err, "failed to find client")
+	}
+	if subtle.ConstantTimeCompare([]byte(storedSecret), []byte(secret)) != 1 {
+		return Principal{}, AccessToken{}, errors.Wrapf(ErrClientInvalid, "invalid secret for client %s", clientID)
+	}
+
+	clientScopes, err := findClientScopes(tx, clientID)
+	if err != nil {
+		return Principal{}, AccessToken{}, err
+	}
+	if len(scopes) == 0 {
+		scopes = clientScopes
+	}
+	for _, scope := range scopes {
+		if !hasScope(clientScopes, scope) {
+			return Principal{}, AccessToken{}, errors.Wrapf(ErrScopeInvalid, "client %s lacks scope %s", clientID, scope)
+		}
+		if scope.Internal() && !internal {
+			return Principal{}, AccessToken{}, errors.Wrapf(ErrScopeInvalid, "client %s isn't internal, so may not have scope %s", clientID, scope)
+		}
+	}
+
+	var sessionID SessionID
+	err = tx.QueryRow(`
+		insert into oauth_sessions(client_id, owner_type, owner_id, created_at, updated_at) values ($1, 'client', $1, now(), now()) returning id
+	`, clientID).Scan(&sessionID)
+	if err != nil {
+		return Principal{}, AccessToken{}, dbError(err, "failed to add client session")
+	}
+
+	for _, scope := range scopes {
+		_, err = tx.Exec(`
+			insert into oauth_session_scopes(session_id, scope_id, created_at, updated_at) values ($1, $2, now(), now())
+		`, sessionID, scope)
+		if err != nil {
+			return Principal{}, AccessToken{}, dbError(err, "failed to add session scope")
+		}
+	}
+
+	accessToken, _, err := addAccessToken(tx, sessionID, accessTokenExpiration)
+	if err != nil {
+		return Principal{}, AccessToken{}, err
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		return Principal{}, AccessToken{}, dbError(err, "failed to commit client session")
+	}
+
+	return Principal{OwnerType: ClientOwner, ClientID: clientID, Scopes: scopes}, accessToken, nil
+}
+
+func findClientScopes(tx *sql.Tx, clientID ClientID) ([]Scope, error) {
+	rows, err := tx.Query(`
+		select scope_id from oauth_client_scopes where client_id = $1
+	`, clientID)
+	if err != nil {
+		return nil, dbError(err, "failed to find client scopes")
+	}
+	defer rows.Close()
+
+	scopes := []Scope{}
+	for rows.Next() {
+		var scope Scope
+		err = rows.Scan(&scope)
+		if err != nil {
+			return nil, dbError(err, "failed to find client scopes")
+		}
+		scopes = append(scopes, scope)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, dbError(err, "failed to find client scopes")
+	}
+
+	sort.Slice(scopes, func(i, j int) bool { return scopes[i] < scopes[j] })
+	return scopes, nil
+}
@@ -0,0 +1,68 @@
+package auth_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/thematthopkins/impact-go/auth"
+	"github.com/thematthopkins/impact-go/testdb"
+)
+
+func TestPrincipal(t *testing.T) {
+	user := auth.Principal{OwnerType: auth.UserOwner, UserID: 1234}
+	assert.True(t, user.IsUser())
+	assert.False(t, user.HasScope("reports"))
+
+	client := auth.Principal{OwnerType: auth.ClientOwner, ClientID: "partner", Scopes: []auth.Scope{"reports"}}
+	assert.False(t, client.IsUser())
+	assert.True(t, client.HasScope("reports"))
+}
+
+func TestAddClientSessionInvalidClient(t *testing.T) {
+	db := testdb.Setup()
+	_, _, err := auth.AddClientSession(db, "unknown-client", "secret", nil, time.Now().Add(time.Minute))
+	assert.Equal(t, auth.ErrClientInvalid, errors.Cause(err))
+}
+
+func TestScopeInternal(t *testing.T) {
+	assert.True(t, auth.Scope("internal:assessment:export").Internal())
+	assert.False(t, auth.Scope("reports").Internal())
+	assert.False(t, auth.Scope("assessment:internal:export").Internal())
+}
+
+func TestAddClientSessionPartnerInternalScope(t *testing.T) {
+	db := testdb.Setup()
+	_, err := db.Exec(`
+		insert into oauth_clients(id, secret, name, internal, created_at, updated_at)
+		values ('partner-internal-scope', 'secret', 'partner-internal-scope', false, now(), now())
+	`)
+	assert.NoError(t, err)
+	_, err = db.Exec(`
+		insert into oauth_scopes(id, description, created_at, updated_at)
+		values ('internal:assessment:export', 'export any assessment', now(), now())
+		on conflict do nothing
+	`)
+	assert.NoError(t, err)
+	_, err = db.Exec(`
+		insert into oauth_client_scopes(client_id, scope_id, created_at, updated_at)
+		values ('partner-internal-scope', 'internal:assessment:export', now(), now())
+	`)
+	assert.NoError(t, err)
+
+	_, _, err = auth.AddClientSession(db, "partner-internal-scope", "secret", nil, time.Now().Add(time.Minute))
+	assert.Equal(t, auth.ErrScopeInvalid, errors.Cause(err))
+}
+
+func TestPrincipalImpersonated(t *testing.T) {
+	impersonated := auth.Principal{OwnerType: auth.UserOwner, UserID: 1234, ImpersonatorID: 1}
+	assert.True(t, impersonated.IsImpersonated())
+	assert.True(t, impersonated.Permits(auth.ViewAssessment))
+	assert.False(t, impersonated.Permits(auth.SubmitAssessment))
+	assert.False(t, impersonated.Permits(auth.ChangeCredentials))
+
+	user := auth.Principal{OwnerType: auth.UserOwner, UserID: 1234}
+	assert.False(t, user.IsImpersonated())
+	assert.True(t, user.Permits(auth.SubmitAssessment))
+}
@@ -0,0 +1,82 @@
+package auth
+
+import (
+	"net"
+	"net/http"
+	"strings"
+	"sync"
+
+	"github.com/pkg/errors"
+)
+
+var trustedProxies struct {
+	sync.RWMutex
+	networks []*net.IPNet
+}
+
+// SetTrustedProxies sets the networks, in CIDR notation, of the load balancers and proxies in
+// front of the service, whose X-Forwarded-For headers are trusted to name the client
+func SetTrustedProxies(cidrs []string) error {
+	networks := make([]*net.IPNet, 0, len(cidrs))
+	for _, cidr := range cidrs {
+		_, network, err := net.ParseCIDR(strings.TrimSpace(cidr))
+		if err != nil {
+			return errors.Wrapf(ErrInvalidInput, "trusted proxy %s", cidr)
+		}
+		networks = append(networks, network)
+	}
+
+	trustedProxies.Lock()
+	defer trustedProxies.Unlock()
+	trustedProxies.networks = networks
+	return nil
+}
+
+// clientIP of r, its remote address unless that's a trusted proxy, in which case the last
+// address of X-Forwarded-For that isn't one
+func clientIP(r *http.Request) string {
+	ip := remoteIP(r)
+	forwarded := forwardedFor(r)
+	for i := len(forwarded) - 1; i >= 0 && isTrustedProxy(ip); i-- {
+		ip = forwarded[i]
+	}
+	return ip
+}
+
+// remoteIP of r, without its port
+func remoteIP(r *http.Request) string {
+	ip, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return ip
+}
+
+// forwardedFor lists the addresses of every X-Forwarded-For header, client first
+func forwardedFor(r *http.Request) []string {
+	result := []string{}
+	for _, header := range r.Header.Values("X-Forwarded-For") {
+		for _, ip := range strings.Split(header, ",") {
+			if ip = strings.TrimSpace(ip); ip != "" {
+				result = append(result, ip)
+			}
+		}
+	}
+	return result
+}
+
+func isTrustedProxy(ip string) bool {
+	parsed := net.ParseIP(ip)
+	if parsed == nil {
+		return false
+	}
+
+	trustedProxies.RLock()
+	defer trustedProxies.RUnlock()
+	for _, network := range trustedProxies.networks {
+		if network.Contains(parsed) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,31 @@
+package auth
+
+import (
+	"net/http/httptest"
+	"testing"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestClientIP(t *testing.T) {
+	assert.NoError(t, SetTrustedProxies([]string{"10.0.0.0/8", " 192.168.1.1/32"}))
+	defer SetTrustedProxies(nil)
+
+	request := httptest.NewRequest("GET", "/", nil)
+	request.RemoteAddr = "203.0.113.9:1234"
+	request.Header.Set("X-Forwarded-For", "198.51.100.1")
+	assert.Equal(t, "203.0.113.9", clientIP(request), "untrusted sender's header ignored")
+
+	request.RemoteAddr = "10.0.0.1:1234"
+	request.Header.Set("X-Forwarded-For", "198.51.100.7, 198.51.100.1, 192.168.1.1")
+	assert.Equal(t, "198.51.100.1", clientIP(request), "last address not added by a trusted proxy")
+
+	request.Header.Del("X-Forwarded-For")
+	assert.Equal(t, "10.0.0.1", clientIP(request))
+}
+
+func TestSetTrustedProxiesInvalid(t *testing.T) {
+	err := SetTrustedProxies([]string{"10.0.0.1"})
+	assert.Equal(t, ErrInvalidInput, errors.Cause(err))
+}
@@ -0,0 +1,27 @@
+package auth
+
+import (
+	"github.com/lib/pq"
+	"github.com/pkg/errors"
+)
+
+// ErrDBUnavailable the database couldn't be reached or failed to complete the query
+var ErrDBUnavailable = errors.New("database unavailable")
+
+// ErrInvalidInput the supplied values were rejected
+var ErrInvalidInput = errors.New("invalid input")
+
+// ErrNotFound a referenced entry doesn't exist
+var ErrNotFound = errors.New("not found")
+
+// dbError classifies err from the database as ErrInvalidInput when postgres rejected the
+// data, such as a constraint violation, and ErrDBUnavailable otherwise
+func dbError(err error, message string) error {
+	if pqErr, ok := err.(*pq.Error); ok {
+		switch pqErr.Code.Class() {
+		case "22", "23":
+			return errors.Wrapf(ErrInvalidInput, "%s: %v", message, err)
+		}
+	}
+	return errors.Wrapf(ErrDBUnavailable, "%s: %v", message, err)
+}
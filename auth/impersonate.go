@@ -0,0 +1,107 @@
+package auth
+
+import (
+	"database/sql"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// ChangeCredentials covers revoking other sessions and changing passwords
+const ChangeCredentials Action = "credentials"
+
+// impersonationRestricted actions staff can't take on behalf of a user
+var impersonationRestricted = map[Action]struct{}{
+	SubmitAssessment:  {},
+	ManageAccess:      {},
+	ChangeCredentials: {},
+}
+
+// IsImpersonated if a staff member is acting as the user
+func (p Principal) IsImpersonated() bool {
+	return p.ImpersonatorID != 0
+}
+
+// Permits reports if the principal may take action at all, regardless of its roles
+func (p Principal) Permits(action Action) bool {
+	if !p.IsImpersonated() {
+		return true
+	}
+	_, restricted := impersonationRestricted[action]
+	return !restricted
+}
+
+// Impersonate issues staffSession's staff member an AccessToken acting as userID, on a
+// session of the same client marked with the staff member.  No RefreshToken is issued.
+func Impersonate(db *sql.DB, staffSession Session, userID UserID, accessTokenExpiration time.Time) (Session, AccessToken, error) {
+	if !staffSession.IsUser() || staffSession.IsImpersonated() {
+		return Session{}, AccessToken{}, errors.Wrap(ErrForbidden, "only staff users may impersonate")
+	}
+
+	tx, err := db.Begin()
+	if err != nil {
+		return Session{}, AccessToken{}, dbError(err, "failed to begin transaction")
+	}
+	defer tx.Rollback()
+
+	var userType string
+	err = tx.QueryRow(`
+		select user_type from impact_user where id = $1
+	`, staffSession.UserID).Scan(&userType)
+	if err == sql.ErrNoRows {
+		userType = ""
+	} else if err != nil {
+		return Session{}, AccessToken{}, dbError(err, "failed to find impersonator")
+	}
+	if Role(userType) != RoleStaff {
+		return Session{}, AccessToken{}, errors.Wrapf(ErrForbidden, "user %d isn't staff", staffSession.UserID)
+	}
+
+	var exists bool
+	err = tx.QueryRow(`
+		select exists(select 1 from impact_user where id = $1)
+	`, userID).Scan(&exists)
+	if err != nil {
+		return Session{}, AccessToken{}, dbError(err, "failed to find impersonated user")
+	}
+	if !exists {
+		return Session{}, AccessToken{}, errors.Wrapf(ErrNotFound, "user %d", userID)
+	}
+
+	session := Session{
+		Principal: Principal{OwnerType: UserOwner, UserID: userID, ImpersonatorID: staffSession.UserID},
+	}
+	err = tx.QueryRow(`
+		insert into oauth_sessions(client_id, owner_type, owner_id, impersonator_id, created_at, updated_at)
+		select client_id, 'user', $2, $3, now(), now() from oauth_sessions where id = $1
+		returning id
+	`, staffSession.ID, userID, staffSession.UserID).Scan(&session.ID)
+	if err == sql.ErrNoRows {
+		return Session{}, AccessToken{}, errors.Wrapf(ErrSessionInvalid, "session %d", staffSession.ID)
+	} else if err != nil {
+		return Session{}, AccessToken{}, dbError(err, "failed to add impersonation session")
+	}
+
+	accessToken, _, err := addAccessToken(tx, session.ID, accessTokenExpiration)
+	if err != nil {
+		return Session{}, AccessToken{}, err
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		return Session{}, AccessToken{}, dbError(err, "failed to commit impersonation session")
+	}
+
+	return session, accessToken, nil
+}
+
+// AuditImpersonation records a request made with an impersonation session
+func AuditImpersonation(db *sql.DB, session Session, method string, path string) error {
+	_, err := db.Exec(`
+		insert into impersonation_audit_log(session_id, impersonator_id, user_id, method, path, created_at) values ($1, $2, $3, $4, $5, now())
+	`, session.ID, session.ImpersonatorID, session.UserID, method, path)
+	if err != nil {
+		return dbError(err, "failed to audit impersonation")
+	}
+	return nil
+}
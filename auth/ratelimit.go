@@ -0,0 +1,225 @@
+package auth
+
+import (
+	"container/list"
+	"database/sql"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// tokenPrefixLength of the presented token limited together, so guesses spread across
+// many IPs are still slowed
+const tokenPrefixLength = 8
+
+// memoryAttemptKeys held by the default TokenLimiter's store
+const memoryAttemptKeys = 100000
+
+// Attempts is the failure history of a key
+type Attempts struct {
+	Failures    int
+	LastFailure time.Time
+}
+
+// AttemptStore tracks failed attempts, forgetting a key's failures once none happen for window
+type AttemptStore interface {
+	AddFailure(key string, now time.Time, window time.Duration) (Attempts, error)
+	// Get the failures of key without adding one, none once its window has passed
+	Get(key string, now time.Time, window time.Duration) (Attempts, error)
+}
+
+// Limiter backs off clients presenting invalid tokens.  After free failures, each further
+// failure doubles the wait before the next attempt, from base up to max.  While backing off,
+// every token the client presents is refused, valid or not, so RetryAfter is checked before
+// validating and Failed after a token turns out invalid.
+type Limiter struct {
+	store  AttemptStore
+	free   int
+	base   time.Duration
+	max    time.Duration
+	window time.Duration
+	now    func() time.Time
+}
+
+// TokenLimiter is applied to failed token validations by the routes, in memory unless replaced
+var TokenLimiter = NewTokenLimiter(NewMemoryAttemptStore(memoryAttemptKeys))
+
+// NewTokenLimiter allows 5 failures per hour, then backs off from a second to 15 minutes
+func NewTokenLimiter(store AttemptStore) *Limiter {
+	return NewLimiter(store, 5, time.Second, 15*time.Minute, time.Hour)
+}
+
+// NewLimiter allowing free failures per key before backing off, forgetting them after window
+func NewLimiter(store AttemptStore, free int, base time.Duration, max time.Duration, window time.Duration) *Limiter {
+	return &Limiter{store: store, free: free, base: base, max: max, window: window, now: time.Now}
+}
+
+// Failed records token from r as invalid, logging the security event, and returns how long
+// its sender must wait before presenting another token, zero if it needn't
+func (l *Limiter) Failed(r *http.Request, token string) (time.Duration, error) {
+	now := l.now()
+	var wait time.Duration
+	for _, key := range attemptKeys(r, token) {
+		attempts, err := l.store.AddFailure(key, now, l.window)
+		if err != nil {
+			return 0, err
+		}
+		backoff := l.backoff(attempts.Failures)
+		if backoff > 0 {
+			fmt.Printf("security event: repeated invalid tokens, key %s, %d failures, backing off %v\n", key, attempts.Failures, backoff)
+		}
+		if backoff > wait {
+			wait = backoff
+		}
+	}
+	fmt.Printf("security event: invalid token from %s %s %s\n", clientIP(r), r.Method, r.URL.Path)
+	return wait, nil
+}
+
+// RetryAfter is how long the sender of r, or of tokens sharing token's prefix, must still wait
+// after earlier failures, zero if it needn't.  No failure is recorded.
+func (l *Limiter) RetryAfter(r *http.Request, token string) (time.Duration, error) {
+	now := l.now()
+	var wait time.Duration
+	for _, key := range attemptKeys(r, token) {
+		attempts, err := l.store.Get(key, now, l.window)
+		if err != nil {
+			return 0, err
+		}
+		remaining := attempts.LastFailure.Add(l.backoff(attempts.Failures)).Sub(now)
+		if attempts.Failures > 0 && remaining > wait {
+			wait = remaining
+		}
+	}
+	return wait, nil
+}
+
+func (l *Limiter) backoff(failures int) time.Duration {
+	if failures <= l.free {
+		return 0
+	}
+	wait := l.base
+	for i := l.free + 1; i < failures && wait < l.max; i++ {
+		wait *= 2
+	}
+	if wait > l.max {
+		wait = l.max
+	}
+	return wait
+}
+
+func attemptKeys(r *http.Request, token string) []string {
+	prefix := token
+	if len(prefix) > tokenPrefixLength {
+		prefix = prefix[:tokenPrefixLength]
+	}
+	return []string{"ip:" + clientIP(r), "token:" + prefix}
+}
+
+// MemoryAttemptStore is an AttemptStore for a single instance, holding up to maxKeys keys
+type MemoryAttemptStore struct {
+	mu      sync.Mutex
+	maxKeys int
+	entries map[string]*list.Element
+	// order of the keys, most recently failed first
+	order *list.List
+}
+
+type attemptEntry struct {
+	key      string
+	attempts Attempts
+}
+
+// NewMemoryAttemptStore with no failures, holding up to maxKeys keys
+func NewMemoryAttemptStore(maxKeys int) *MemoryAttemptStore {
+	return &MemoryAttemptStore{maxKeys: maxKeys, entries: map[string]*list.Element{}, order: list.New()}
+}
+
+// AddFailure to key, dropping the keys whose window has passed.  When full, the key
+// failing least recently is dropped to make room.
+func (s *MemoryAttemptStore) AddFailure(key string, now time.Time, window time.Duration) (Attempts, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for back := s.order.Back(); back != nil && now.Sub(back.Value.(*attemptEntry).attempts.LastFailure) > window; back = s.order.Back() {
+		s.removeElement(back)
+	}
+
+	element, ok := s.entries[key]
+	if !ok {
+		if s.order.Len() > 0 && s.order.Len() >= s.maxKeys {
+			s.removeElement(s.order.Back())
+		}
+		element = s.order.PushFront(&attemptEntry{key: key})
+		s.entries[key] = element
+	}
+
+	entry := element.Value.(*attemptEntry)
+	entry.attempts.Failures++
+	entry.attempts.LastFailure = now
+	s.order.MoveToFront(element)
+	return entry.attempts, nil
+}
+
+// Get the failures of key, leaving the order of the keys as it is
+func (s *MemoryAttemptStore) Get(key string, now time.Time, window time.Duration) (Attempts, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	element, ok := s.entries[key]
+	if !ok {
+		return Attempts{}, nil
+	}
+	attempts := element.Value.(*attemptEntry).attempts
+	if now.Sub(attempts.LastFailure) > window {
+		return Attempts{}, nil
+	}
+	return attempts, nil
+}
+
+func (s *MemoryAttemptStore) removeElement(element *list.Element) {
+	s.order.Remove(element)
+	delete(s.entries, element.Value.(*attemptEntry).key)
+}
+
+// PostgresAttemptStore is an AttemptStore shared by every instance, in auth_failed_attempts.
+// Times are stored in UTC, last_failure being a timestamp without time zone.
+type PostgresAttemptStore struct {
+	db *sql.DB
+}
+
+// NewPostgresAttemptStore using db
+func NewPostgresAttemptStore(db *sql.DB) *PostgresAttemptStore {
+	return &PostgresAttemptStore{db: db}
+}
+
+// AddFailure to key, restarting its count when its window has passed
+func (s *PostgresAttemptStore) AddFailure(key string, now time.Time, window time.Duration) (Attempts, error) {
+	var attempts Attempts
+	err := s.db.QueryRow(`
+		insert into auth_failed_attempts(key, failures, last_failure) values ($1, 1, $2)
+		on conflict (key) do update set
+			failures = case when auth_failed_attempts.last_failure < $3 then 1 else auth_failed_attempts.failures + 1 end,
+			last_failure = $2
+		returning failures, last_failure
+	`, key, now.UTC(), now.Add(-window).UTC()).Scan(&attempts.Failures, &attempts.LastFailure)
+	if err != nil {
+		return Attempts{}, dbError(err, "failed to record failed attempt")
+	}
+	return attempts, nil
+}
+
+// Get the failures of key within window
+func (s *PostgresAttemptStore) Get(key string, now time.Time, window time.Duration) (Attempts, error) {
+	var attempts Attempts
+	err := s.db.QueryRow(`
+		select failures, last_failure from auth_failed_attempts where key = $1 and last_failure >= $2
+	`, key, now.Add(-window).UTC()).Scan(&attempts.Failures, &attempts.LastFailure)
+	if err == sql.ErrNoRows {
+		return Attempts{}, nil
+	} else if err != nil {
+		return Attempts{}, dbError(err, "failed to find failed attempts")
+	}
+	return attempts, nil
+}
@@ -0,0 +1,113 @@
+package auth
+
+import (
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func testLimiter() (*Limiter, *time.Time) {
+	now := time.Unix(1000, 0)
+	limiter := NewLimiter(NewMemoryAttemptStore(100), 2, time.Second, 4*time.Second, time.Minute)
+	limiter.now = func() time.Time { return now }
+	return limiter, &now
+}
+
+func TestLimiterBackoff(t *testing.T) {
+	limiter, now := testLimiter()
+	request := httptest.NewRequest("GET", "/", nil)
+	request.RemoteAddr = "10.0.0.1:1234"
+
+	failed := func(token string) time.Duration {
+		wait, err := limiter.Failed(request, token)
+		assert.NoError(t, err)
+		return wait
+	}
+
+	for i := 0; i < 2; i++ {
+		assert.Equal(t, time.Duration(0), failed("guess"), "free failures")
+	}
+
+	assert.Equal(t, time.Second, failed("guess"))
+	assert.Equal(t, 2*time.Second, failed("guess"))
+	failed("guess")
+	assert.Equal(t, 4*time.Second, failed("guess"), "capped at max")
+
+	*now = now.Add(2 * time.Minute)
+	assert.Equal(t, time.Duration(0), failed("guess"), "forgotten after window")
+}
+
+func TestLimiterRetryAfter(t *testing.T) {
+	limiter, now := testLimiter()
+	request := httptest.NewRequest("GET", "/", nil)
+	request.RemoteAddr = "10.0.0.1:1234"
+
+	retryAfter := func(token string) time.Duration {
+		wait, err := limiter.RetryAfter(request, token)
+		assert.NoError(t, err)
+		return wait
+	}
+
+	for i := 0; i < 3; i++ {
+		assert.Equal(t, time.Duration(0), retryAfter("valid-token"), "checking records no failure")
+	}
+	for i := 0; i < 3; i++ {
+		limiter.Failed(request, "guess")
+	}
+	assert.Equal(t, time.Second, retryAfter("valid-token"), "backing off the sender")
+
+	*now = now.Add(500 * time.Millisecond)
+	assert.Equal(t, 500*time.Millisecond, retryAfter("valid-token"))
+
+	*now = now.Add(time.Second)
+	assert.Equal(t, time.Duration(0), retryAfter("valid-token"), "waited long enough")
+}
+
+func TestLimiterKeys(t *testing.T) {
+	limiter, _ := testLimiter()
+	request := httptest.NewRequest("GET", "/", nil)
+	request.RemoteAddr = "10.0.0.1:1234"
+	other := httptest.NewRequest("GET", "/", nil)
+	other.RemoteAddr = "10.0.0.2:1234"
+
+	for i := 0; i < 2; i++ {
+		_, err := limiter.Failed(request, "prefix01-guess")
+		assert.NoError(t, err)
+	}
+
+	wait, err := limiter.Failed(other, "prefix01-other")
+	assert.NoError(t, err)
+	assert.Equal(t, time.Second, wait, "limited by token prefix")
+
+	wait, err = limiter.Failed(request, "unrelated")
+	assert.NoError(t, err)
+	assert.Equal(t, time.Second, wait, "limited by ip")
+
+	third := httptest.NewRequest("GET", "/", nil)
+	third.RemoteAddr = "10.0.0.3:1234"
+	wait, err = limiter.Failed(third, "different")
+	assert.NoError(t, err)
+	assert.Equal(t, time.Duration(0), wait)
+}
+
+func TestMemoryAttemptStoreBounded(t *testing.T) {
+	store := NewMemoryAttemptStore(2)
+	now := time.Unix(1000, 0)
+
+	store.AddFailure("a", now, time.Minute)
+	store.AddFailure("b", now.Add(time.Second), time.Minute)
+	store.AddFailure("a", now.Add(2*time.Second), time.Minute)
+	store.AddFailure("c", now.Add(3*time.Second), time.Minute)
+
+	assert.Len(t, store.entries, 2)
+	assert.NotContains(t, store.entries, "b", "least recently failed dropped")
+
+	attempts, err := store.AddFailure("a", now.Add(4*time.Second), time.Minute)
+	assert.NoError(t, err)
+	assert.Equal(t, 3, attempts.Failures)
+
+	store.AddFailure("d", now.Add(2*time.Minute), time.Minute)
+	assert.Len(t, store.entries, 1, "keys past their window dropped")
+}
@@ -0,0 +1,124 @@
+package auth
+
+import (
+	"database/sql"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// ErrRefreshTokenInvalid indicates the refresh token doesn't exist or has expired
+var ErrRefreshTokenInvalid = errors.New("refresh token invalid")
+
+// ErrRefreshTokenReused indicates an already exchanged refresh token was
+// presented again, so its session has been revoked
+var ErrRefreshTokenReused = errors.New("refresh token reused")
+
+// RefreshSessionToken exchanges refreshToken for a new AccessToken and
+// RefreshToken on the same session, invalidating the old pair
+func RefreshSessionToken(db *sql.DB, refreshToken string, accessTokenExpiration time.Time, refreshTokenExpiration time.Time) (AccessToken, RefreshToken, error) {
+	err := checkExpirations(accessTokenExpiration, refreshTokenExpiration)
+	if err != nil {
+		return AccessToken{}, RefreshToken{}, err
+	}
+
+	tx, err := db.Begin()
+	if err != nil {
+		return AccessToken{}, RefreshToken{}, dbError(err, "failed to begin transaction")
+	}
+	defer tx.Rollback()
+
+	now := time.Now().Unix()
+	var sessionID SessionID
+	var accessTokenID string
+	var refreshTokenID string
+	var hashed bool
+	var expireTime int64
+	err = tx.QueryRow(`
+		select
+			oauth_access_tokens.session_id,
+			oauth_access_tokens.id,
+			oauth_refresh_tokens.id,
+			oauth_refresh_tokens.hashed,
+			oauth_refresh_tokens.expire_time
+		from
+			oauth_refresh_tokens
+			join oauth_access_tokens on oauth_refresh_tokens.access_token_id = oauth_access_tokens.id
+		where
+			`+tokenMatch("oauth_refresh_tokens")+`
+		for update
+	`, tokenArgs(refreshToken)...).Scan(&sessionID, &accessTokenID, &refreshTokenID, &hashed, &expireTime)
+
+	if err == sql.ErrNoRows {
+		return AccessToken{}, RefreshToken{}, revokeReusedSession(tx, refreshToken)
+	} else if err != nil {
+		return AccessToken{}, RefreshToken{}, dbError(err, "failed to find refresh token")
+	}
+
+	if expireTime <= now || !storedTokenMatches(refreshTokenID, hashed, refreshToken) {
+		return AccessToken{}, RefreshToken{}, errors.Wrapf(ErrRefreshTokenInvalid, "refresh token of session %d expired or mismatched", sessionID)
+	}
+
+	_, err = tx.Exec(`
+		insert into oauth_used_refresh_tokens(id, hashed, session_id, expire_time, created_at, updated_at) values($1, $2, $3, $4, now(), now())
+	`, refreshTokenID, hashed, sessionID, expireTime)
+	if err != nil {
+		return AccessToken{}, RefreshToken{}, dbError(err, "failed to record used refresh token")
+	}
+
+	_, err = tx.Exec(`
+		delete from oauth_refresh_tokens where id = $1
+	`, refreshTokenID)
+	if err != nil {
+		return AccessToken{}, RefreshToken{}, dbError(err, "failed to delete refresh token")
+	}
+
+	_, err = tx.Exec(`
+		delete from oauth_access_tokens where id = $1
+	`, accessTokenID)
+	if err != nil {
+		return AccessToken{}, RefreshToken{}, dbError(err, "failed to delete access token")
+	}
+
+	accessToken, newRefreshToken, err := addSessionToken(tx, sessionID, accessTokenExpiration, refreshTokenExpiration)
+	if err != nil {
+		return AccessToken{}, RefreshToken{}, err
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		return AccessToken{}, RefreshToken{}, dbError(err, "failed to commit refreshed token")
+	}
+
+	ValidationCache.RemoveSession(sessionID)
+	return accessToken, newRefreshToken, nil
+}
+
+// revokeReusedSession deletes the session of refreshToken when it has already
+// been exchanged
+func revokeReusedSession(tx *sql.Tx, refreshToken string) error {
+	var sessionID SessionID
+	err := tx.QueryRow(`
+		select session_id from oauth_used_refresh_tokens where `+tokenMatch("oauth_used_refresh_tokens")+`
+	`, tokenArgs(refreshToken)...).Scan(&sessionID)
+	if err == sql.ErrNoRows {
+		return errors.Wrap(ErrRefreshTokenInvalid, "no refresh token matches")
+	} else if err != nil {
+		return dbError(err, "failed to find used refresh token")
+	}
+
+	_, err = tx.Exec(`
+		delete from oauth_sessions where id = $1
+	`, sessionID)
+	if err != nil {
+		return dbError(err, "failed to revoke session")
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		return dbError(err, "failed to commit session revocation")
+	}
+
+	ValidationCache.RemoveSession(sessionID)
+	return errors.Wrapf(ErrRefreshTokenReused, "session %d revoked", sessionID)
+}
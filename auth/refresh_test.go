@@ -0,0 +1,76 @@
+package auth_test
+
+import (
+	"database/sql"
+	"fmt"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/thematthopkins/impact-go/auth"
+	"github.com/thematthopkins/impact-go/testdb"
+)
+
+func validateToken(db *sql.DB, token string) (auth.UserID, error) {
+	var request = httptest.NewRequest("GET", "/", nil)
+	request.Header.Add("Authorization", fmt.Sprintf("Bearer %v", token))
+	principal, err := auth.Validate(request, db)
+	return principal.UserID, err
+}
+
+func TestRefreshSessionToken(t *testing.T) {
+	db := testdb.Setup()
+	sessionID, err := auth.AddSession(db, "impact.development", 1234)
+	assert.NoError(t, err)
+	accessToken, refreshToken, err := auth.AddSessionToken(db, sessionID, time.Now().Add(time.Minute), time.Now().Add(time.Minute))
+	assert.NoError(t, err)
+
+	newAccessToken, newRefreshToken, err := auth.RefreshSessionToken(db, refreshToken.Token, time.Now().Add(time.Minute), time.Now().Add(time.Minute))
+	assert.NoError(t, err)
+	assert.NotEqual(t, refreshToken.Token, newRefreshToken.Token)
+
+	userID, err := validateToken(db, newAccessToken.Token)
+	assert.NoError(t, err)
+	assert.Equal(t, auth.UserID(1234), userID)
+
+	_, err = validateToken(db, accessToken.Token)
+	assert.Equal(t, auth.ErrSessionInvalid, errors.Cause(err))
+}
+
+func TestRefreshSessionTokenInvalid(t *testing.T) {
+	db := testdb.Setup()
+	_, _, err := auth.RefreshSessionToken(db, "invalidToken", time.Now().Add(time.Minute), time.Now().Add(time.Minute))
+	assert.Equal(t, auth.ErrRefreshTokenInvalid, errors.Cause(err))
+	assert.NotContains(t, err.Error(), "invalidToken")
+}
+
+func TestRefreshSessionTokenExpired(t *testing.T) {
+	db := testdb.Setup()
+	sessionID, err := auth.AddSession(db, "impact.development", 1234)
+	assert.NoError(t, err)
+	_, refreshToken, err := auth.AddSessionToken(db, sessionID, time.Now().Add(-time.Minute), time.Now().Add(-time.Second))
+	assert.NoError(t, err)
+
+	_, _, err = auth.RefreshSessionToken(db, refreshToken.Token, time.Now().Add(time.Minute), time.Now().Add(time.Minute))
+	assert.Equal(t, auth.ErrRefreshTokenInvalid, errors.Cause(err))
+}
+
+func TestRefreshSessionTokenReuseRevokesSession(t *testing.T) {
+	db := testdb.Setup()
+	sessionID, err := auth.AddSession(db, "impact.development", 1234)
+	assert.NoError(t, err)
+	_, refreshToken, err := auth.AddSessionToken(db, sessionID, time.Now().Add(time.Minute), time.Now().Add(time.Minute))
+	assert.NoError(t, err)
+
+	newAccessToken, _, err := auth.RefreshSessionToken(db, refreshToken.Token, time.Now().Add(time.Minute), time.Now().Add(time.Minute))
+	assert.NoError(t, err)
+
+	_, _, err = auth.RefreshSessionToken(db, refreshToken.Token, time.Now().Add(time.Minute), time.Now().Add(time.Minute))
+	assert.Equal(t, auth.ErrRefreshTokenReused, errors.Cause(err))
+	assert.NotContains(t, err.Error(), refreshToken.Token)
+
+	_, err = validateToken(db, newAccessToken.Token)
+	assert.Equal(t, auth.ErrSessionInvalid, errors.Cause(err))
+}
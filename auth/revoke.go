@@ -0,0 +1,59 @@
+package auth
+
+import "database/sql"
+
+// RevokeToken invalidates an access or refresh token along with the other
+// half of its pair.  Unknown tokens are ignored.
+func RevokeToken(db *sql.DB, token string) error {
+	rows, err := db.Query(`
+		delete from oauth_access_tokens
+		where
+			`+tokenMatch("oauth_access_tokens")+`
+			or oauth_access_tokens.id in (select access_token_id from oauth_refresh_tokens where `+tokenMatch("oauth_refresh_tokens")+`)
+		returning session_id
+	`, tokenArgs(token)...)
+	if err != nil {
+		return dbError(err, "failed to revoke token")
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var sessionID SessionID
+		err = rows.Scan(&sessionID)
+		if err != nil {
+			return dbError(err, "failed to revoke token")
+		}
+		ValidationCache.RemoveSession(sessionID)
+	}
+	if err = rows.Err(); err != nil {
+		return dbError(err, "failed to revoke token")
+	}
+
+	return nil
+}
+
+// RevokeSession invalidates every token of the session
+func RevokeSession(db *sql.DB, sessionID SessionID) error {
+	_, err := db.Exec(`
+		delete from oauth_sessions where id = $1
+	`, sessionID)
+	if err != nil {
+		return dbError(err, "failed to revoke session")
+	}
+
+	ValidationCache.RemoveSession(sessionID)
+	return nil
+}
+
+// RevokeUserSessions invalidates every session of the user
+func RevokeUserSessions(db *sql.DB, userID UserID) error {
+	_, err := db.Exec(`
+		delete from oauth_sessions where owner_type = 'user' and owner_id = $1
+	`, userID)
+	if err != nil {
+		return dbError(err, "failed to revoke user sessions")
+	}
+
+	ValidationCache.RemoveUser(userID)
+	return nil
+}
@@ -0,0 +1,81 @@
+package auth_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/thematthopkins/impact-go/auth"
+	"github.com/thematthopkins/impact-go/testdb"
+)
+
+func TestRevokeAccessToken(t *testing.T) {
+	db := testdb.Setup()
+	accessToken, err := addAccessToken(db, 1234, time.Now().Add(time.Minute))
+	assert.NoError(t, err)
+
+	err = auth.RevokeToken(db, accessToken.Token)
+	assert.NoError(t, err)
+
+	_, err = validateToken(db, accessToken.Token)
+	assert.Equal(t, auth.ErrSessionInvalid, errors.Cause(err))
+}
+
+func TestRevokeRefreshToken(t *testing.T) {
+	db := testdb.Setup()
+	sessionID, err := auth.AddSession(db, "impact.development", 1234)
+	assert.NoError(t, err)
+	accessToken, refreshToken, err := auth.AddSessionToken(db, sessionID, time.Now().Add(time.Minute), time.Now().Add(time.Minute))
+	assert.NoError(t, err)
+
+	err = auth.RevokeToken(db, refreshToken.Token)
+	assert.NoError(t, err)
+
+	_, err = validateToken(db, accessToken.Token)
+	assert.Equal(t, auth.ErrSessionInvalid, errors.Cause(err))
+
+	_, _, err = auth.RefreshSessionToken(db, refreshToken.Token, time.Now().Add(time.Minute), time.Now().Add(time.Minute))
+	assert.Equal(t, auth.ErrRefreshTokenInvalid, errors.Cause(err))
+}
+
+func TestRevokeUnknownToken(t *testing.T) {
+	db := testdb.Setup()
+	err := auth.RevokeToken(db, "unknownToken")
+	assert.NoError(t, err)
+}
+
+func TestRevokeSession(t *testing.T) {
+	db := testdb.Setup()
+	sessionID, err := auth.AddSession(db, "impact.development", 1234)
+	assert.NoError(t, err)
+	accessToken, _, err := auth.AddSessionToken(db, sessionID, time.Now().Add(time.Minute), time.Now().Add(time.Minute))
+	assert.NoError(t, err)
+	otherAccessToken, err := addAccessToken(db, 1234, time.Now().Add(time.Minute))
+	assert.NoError(t, err)
+
+	err = auth.RevokeSession(db, sessionID)
+	assert.NoError(t, err)
+
+	_, err = validateToken(db, accessToken.Token)
+	assert.Equal(t, auth.ErrSessionInvalid, errors.Cause(err))
+
+	_, err = validateToken(db, otherAccessToken.Token)
+	assert.NoError(t, err)
+}
+
+func TestRevokeUserSessions(t *testing.T) {
+	db := testdb.Setup()
+	accessToken, err := addAccessToken(db, 1234, time.Now().Add(time.Minute))
+	assert.NoError(t, err)
+	otherAccessToken, err := addAccessToken(db, 1234, time.Now().Add(time.Minute))
+	assert.NoError(t, err)
+
+	err = auth.RevokeUserSessions(db, 1234)
+	assert.NoError(t, err)
+
+	_, err = validateToken(db, accessToken.Token)
+	assert.Equal(t, auth.ErrSessionInvalid, errors.Cause(err))
+	_, err = validateToken(db, otherAccessToken.Token)
+	assert.Equal(t, auth.ErrSessionInvalid, errors.Cause(err))
+}
@@ -0,0 +1,95 @@
+package auth
+
+import (
+	"database/sql"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// SessionInfo describes an active session to its user
+type SessionInfo struct {
+	ID         SessionID
+	ClientName ClientName
+	CreatedAt  time.Time
+	// LastSeenAt, LastIP and LastUserAgent are of the session's latest flushed request, if any
+	LastSeenAt    *time.Time
+	LastIP        string
+	LastUserAgent string
+}
+
+// ListSessions finds the sessions of userID with an unexpired access or refresh token,
+// most recently seen first.  Sessions of staff impersonating the user aren't included.
+func ListSessions(db *sql.DB, userID UserID) ([]SessionInfo, error) {
+	now := time.Now().Unix()
+	rows, err := db.Query(`
+		select
+			oauth_sessions.id,
+			oauth_clients.name,
+			oauth_sessions.created_at,
+			oauth_sessions.last_seen_at,
+			coalesce(oauth_sessions.last_ip, ''),
+			coalesce(oauth_sessions.last_user_agent, '')
+		from
+			oauth_sessions
+			join oauth_clients on oauth_sessions.client_id = oauth_clients.id
+		where
+			oauth_sessions.owner_type = 'user'
+			and oauth_sessions.owner_id = $1
+			and oauth_sessions.impersonator_id is null
+			and exists (
+				select 1
+				from
+					oauth_access_tokens
+					left join oauth_refresh_tokens on oauth_refresh_tokens.access_token_id = oauth_access_tokens.id
+				where
+					oauth_access_tokens.session_id = oauth_sessions.id
+					and (oauth_access_tokens.expire_time > $2 or oauth_refresh_tokens.expire_time > $2)
+			)
+		order by coalesce(oauth_sessions.last_seen_at, oauth_sessions.created_at) desc, oauth_sessions.id desc
+	`, userID, now)
+	if err != nil {
+		return nil, dbError(err, "failed to list sessions")
+	}
+	defer rows.Close()
+
+	sessions := []SessionInfo{}
+	for rows.Next() {
+		var session SessionInfo
+		var lastSeenAt sql.NullTime
+		err = rows.Scan(&session.ID, &session.ClientName, &session.CreatedAt, &lastSeenAt, &session.LastIP, &session.LastUserAgent)
+		if err != nil {
+			return nil, dbError(err, "failed to list sessions")
+		}
+		if lastSeenAt.Valid {
+			session.LastSeenAt = &lastSeenAt.Time
+		}
+		sessions = append(sessions, session)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, dbError(err, "failed to list sessions")
+	}
+
+	return sessions, nil
+}
+
+// RevokeUserSession invalidates sessionID, returning ErrNotFound unless it belongs to userID
+func RevokeUserSession(db *sql.DB, userID UserID, sessionID SessionID) error {
+	result, err := db.Exec(`
+		delete from oauth_sessions where id = $1 and owner_type = 'user' and owner_id = $2
+	`, sessionID, userID)
+	if err != nil {
+		return dbError(err, "failed to revoke session")
+	}
+
+	deleted, err := result.RowsAffected()
+	if err != nil {
+		return dbError(err, "failed to revoke session")
+	}
+	if deleted == 0 {
+		return errors.Wrapf(ErrNotFound, "session %d of user %d", sessionID, userID)
+	}
+
+	ValidationCache.RemoveSession(sessionID)
+	return nil
+}
@@ -0,0 +1,213 @@
+package auth
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// sweepLockID is the postgres advisory lock held by whichever instance is sweeping
+const sweepLockID int64 = 0x696d70616374
+
+// failedAttemptRetention in auth_failed_attempts, well past any Limiter window
+const failedAttemptRetention = 24 * time.Hour
+
+// emptySessionGrace before a session without tokens is swept, so one created by AddSession
+// isn't removed before AddSessionToken.  Measured by the db's clock, as created_at is set by now()
+// in the db's time zone.
+const emptySessionGrace = time.Hour
+
+// Sweeper deletes expired tokens and empty sessions in batches.  Only one instance sweeps at
+// a time, the one holding the advisory lock; the others skip the run.
+type Sweeper struct {
+	batchSize int
+	interval  time.Duration
+
+	mu    sync.Mutex
+	stats SweepStats
+}
+
+// SweepCounts of the rows deleted from each table
+type SweepCounts struct {
+	AccessTokens      int64
+	RefreshTokens     int64
+	UsedRefreshTokens int64
+	Sessions          int64
+	FailedAttempts    int64
+}
+
+// SweepResult of a single sweep
+type SweepResult struct {
+	// Leader if this instance held the lock and swept
+	Leader bool
+	SweepCounts
+}
+
+// SweepStats totals the sweeps since the Sweeper was created
+type SweepStats struct {
+	Runs    int64
+	Skipped int64
+	Errors  int64
+	Deleted SweepCounts
+	LastRun time.Time
+}
+
+func (c *SweepCounts) add(other SweepCounts) {
+	c.AccessTokens += other.AccessTokens
+	c.RefreshTokens += other.RefreshTokens
+	c.UsedRefreshTokens += other.UsedRefreshTokens
+	c.Sessions += other.Sessions
+	c.FailedAttempts += other.FailedAttempts
+}
+
+// NewSweeper deleting up to batchSize rows per statement, sweeping every interval when Run
+func NewSweeper(batchSize int, interval time.Duration) *Sweeper {
+	return &Sweeper{batchSize: batchSize, interval: interval}
+}
+
+// Stats as of now
+func (s *Sweeper) Stats() SweepStats {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.stats
+}
+
+// Run sweeps every interval until stop is closed
+func (s *Sweeper) Run(db *sql.DB, stop <-chan struct{}) {
+	ticker := time.NewTicker(s.interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+		case <-stop:
+			return
+		}
+
+		result, err := s.Sweep(db)
+		if err != nil {
+			fmt.Println("error sweeping sessions: ", err)
+		} else if result.Leader {
+			fmt.Printf("swept %d access tokens, %d refresh tokens, %d used refresh tokens, %d sessions, %d failed attempts\n",
+				result.AccessTokens, result.RefreshTokens, result.UsedRefreshTokens, result.Sessions, result.FailedAttempts)
+		}
+	}
+}
+
+// Sweep once, if no other instance is sweeping
+func (s *Sweeper) Sweep(db *sql.DB) (SweepResult, error) {
+	result, err := s.sweep(db)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.stats.LastRun = time.Now()
+	if err != nil {
+		s.stats.Errors++
+	} else if !result.Leader {
+		s.stats.Skipped++
+	} else {
+		s.stats.Runs++
+	}
+	s.stats.Deleted.add(result.SweepCounts)
+
+	return result, err
+}
+
+func (s *Sweeper) sweep(db *sql.DB) (SweepResult, error) {
+	ctx := context.Background()
+
+	// advisory locks belong to the connection, so the lock and unlock must share one
+	conn, err := db.Conn(ctx)
+	if err != nil {
+		return SweepResult{}, dbError(err, "failed to connect")
+	}
+	defer conn.Close()
+
+	var result SweepResult
+	err = conn.QueryRowContext(ctx, `select pg_try_advisory_lock($1)`, sweepLockID).Scan(&result.Leader)
+	if err != nil {
+		return SweepResult{}, dbError(err, "failed to acquire sweep lock")
+	}
+	if !result.Leader {
+		return result, nil
+	}
+	defer conn.ExecContext(ctx, `select pg_advisory_unlock($1)`, sweepLockID)
+
+	now := time.Now()
+
+	// refresh tokens first, so access tokens are only kept while they can still be refreshed
+	result.RefreshTokens, err = s.deleteBatches(ctx, conn, `
+		delete from oauth_refresh_tokens where id in (
+			select id from oauth_refresh_tokens where expire_time <= $1 limit $2
+		)
+	`, now.Unix())
+	if err != nil {
+		return result, err
+	}
+
+	result.AccessTokens, err = s.deleteBatches(ctx, conn, `
+		delete from oauth_access_tokens where id in (
+			select id from oauth_access_tokens
+			where
+				expire_time <= $1
+				and not exists (select 1 from oauth_refresh_tokens where access_token_id = oauth_access_tokens.id)
+			limit $2
+		)
+	`, now.Unix())
+	if err != nil {
+		return result, err
+	}
+
+	result.UsedRefreshTokens, err = s.deleteBatches(ctx, conn, `
+		delete from oauth_used_refresh_tokens where id in (
+			select id from oauth_used_refresh_tokens where expire_time <= $1 limit $2
+		)
+	`, now.Unix())
+	if err != nil {
+		return result, err
+	}
+
+	result.Sessions, err = s.deleteBatches(ctx, conn, `
+		delete from oauth_sessions where id in (
+			select id from oauth_sessions
+			where
+				created_at <= now() - make_interval(secs => $1)
+				and not exists (select 1 from oauth_access_tokens where session_id = oauth_sessions.id)
+			limit $2
+		)
+	`, emptySessionGrace.Seconds())
+	if err != nil {
+		return result, err
+	}
+
+	result.FailedAttempts, err = s.deleteBatches(ctx, conn, `
+		delete from auth_failed_attempts where key in (
+			select key from auth_failed_attempts where last_failure <= $1 limit $2
+		)
+	`, now.Add(-failedAttemptRetention))
+	if err != nil {
+		return result, err
+	}
+
+	return result, nil
+}
+
+// deleteBatches runs query, taking the cutoff, or grace period, and batch size, until a batch deletes fewer than batchSize rows
+func (s *Sweeper) deleteBatches(ctx context.Context, conn *sql.Conn, query string, cutoff interface{}) (int64, error) {
+	var total int64
+	for {
+		result, err := conn.ExecContext(ctx, query, cutoff, s.batchSize)
+		if err != nil {
+			return total, dbError(err, "failed to sweep")
+		}
+		deleted, err := result.RowsAffected()
+		if err != nil {
+			return total, dbError(err, "failed to sweep")
+		}
+		total += deleted
+		if deleted < int64(s.batchSize) {
+			return total, nil
+		}
+	}
+}
@@ -0,0 +1,75 @@
+package auth_test
+
+import (
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/thematthopkins/impact-go/auth"
+	"github.com/thematthopkins/impact-go/testdb"
+)
+
+func TestSweep(t *testing.T) {
+	db := testdb.Setup()
+	sessionID, err := auth.AddSession(db, "impact.development", 1234)
+	assert.NoError(t, err)
+	_, _, err = auth.AddSessionToken(db, sessionID, time.Now().Add(-time.Minute), time.Now().Add(-time.Second))
+	assert.NoError(t, err)
+	live, err := addAccessToken(db, 1234, time.Now().Add(time.Minute))
+	assert.NoError(t, err)
+
+	sweeper := auth.NewSweeper(1, time.Minute)
+	result, err := sweeper.Sweep(db)
+	assert.NoError(t, err)
+	assert.True(t, result.Leader)
+	assert.True(t, result.AccessTokens >= 1)
+	assert.True(t, result.RefreshTokens >= 1)
+
+	var count int
+	err = db.QueryRow(`select count(*) from oauth_access_tokens where session_id = $1`, sessionID).Scan(&count)
+	assert.NoError(t, err)
+	assert.Equal(t, 0, count)
+
+	_, err = validateToken(db, live.Token)
+	assert.NoError(t, err)
+
+	stats := sweeper.Stats()
+	assert.Equal(t, int64(1), stats.Runs)
+	assert.Equal(t, result.SweepCounts, stats.Deleted)
+}
+
+func TestSweepEmptySessions(t *testing.T) {
+	db := testdb.Setup()
+	fresh, err := auth.AddSession(db, "impact.development", 1234)
+	assert.NoError(t, err)
+	stale, err := auth.AddSession(db, "impact.development", 1234)
+	assert.NoError(t, err)
+	_, err = db.Exec(`update oauth_sessions set created_at = now() - interval '2 hours' where id = $1`, stale)
+	assert.NoError(t, err)
+
+	_, err = auth.NewSweeper(100, time.Minute).Sweep(db)
+	assert.NoError(t, err)
+
+	var ids []auth.SessionID
+	rows, err := db.Query(`select id from oauth_sessions where id in ($1, $2)`, fresh, stale)
+	assert.NoError(t, err)
+	defer rows.Close()
+	for rows.Next() {
+		var id auth.SessionID
+		assert.NoError(t, rows.Scan(&id))
+		ids = append(ids, id)
+	}
+	assert.Equal(t, []auth.SessionID{fresh}, ids)
+}
+
+func TestSweepDBUnavailable(t *testing.T) {
+	db, err := sql.Open("postgres", "postgres://localhost:1/unavailable?sslmode=disable&connect_timeout=1")
+	assert.NoError(t, err)
+
+	sweeper := auth.NewSweeper(1, time.Minute)
+	_, err = sweeper.Sweep(db)
+	assert.Equal(t, auth.ErrDBUnavailable, errors.Cause(err))
+	assert.Equal(t, int64(1), sweeper.Stats().Errors)
+}
@@ -0,0 +1,68 @@
+package auth
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"sync"
+)
+
+// tokenBytes of randomness in each token, against the 122 bits of a v4 UUID
+const tokenBytes = 32
+
+var tokenKey struct {
+	sync.RWMutex
+	key []byte
+}
+
+// SetTokenKey sets the secret key tokens are hashed with before being stored.
+// Changing it invalidates every token issued under the previous key.
+func SetTokenKey(key []byte) {
+	tokenKey.Lock()
+	defer tokenKey.Unlock()
+	tokenKey.key = append([]byte(nil), key...)
+}
+
+// newToken generates a random, url safe token
+func newToken() (string, error) {
+	b := make([]byte, tokenBytes)
+	_, err := rand.Read(b)
+	if err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+// hashToken is the keyed hash stored in place of token
+func hashToken(token string) string {
+	tokenKey.RLock()
+	defer tokenKey.RUnlock()
+
+	mac := hmac.New(sha256.New, tokenKey.key)
+	mac.Write([]byte(token))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// tokenMatch is the sql condition finding the row of table whose id is stored for a token,
+// given its hash as $1 and, for legacy rows stored unhashed, the raw token as $2
+func tokenMatch(table string) string {
+	return fmt.Sprintf("((%[1]s.id = $1 and %[1]s.hashed) or (%[1]s.id = $2 and not %[1]s.hashed))", table)
+}
+
+// tokenArgs are the $1 and $2 arguments of tokenMatch
+func tokenArgs(token string) []interface{} {
+	return []interface{}{hashToken(token), token}
+}
+
+// storedTokenMatches checks, in constant time, that id is what would be stored for token
+func storedTokenMatches(id string, hashed bool, token string) bool {
+	expected := token
+	if hashed {
+		expected = hashToken(token)
+	}
+	return subtle.ConstantTimeCompare([]byte(id), []byte(expected)) == 1
+}
@@ -0,0 +1,33 @@
+package auth
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewToken(t *testing.T) {
+	a, err := newToken()
+	assert.NoError(t, err)
+	b, err := newToken()
+	assert.NoError(t, err)
+
+	assert.Len(t, a, 43)
+	assert.NotEqual(t, a, b)
+}
+
+func TestHashToken(t *testing.T) {
+	defer SetTokenKey(nil)
+
+	SetTokenKey([]byte("key1"))
+	hashed := hashToken("token")
+	assert.Len(t, hashed, 64)
+	assert.Equal(t, hashed, hashToken("token"))
+	assert.True(t, storedTokenMatches(hashed, true, "token"))
+	assert.False(t, storedTokenMatches(hashed, false, "token"))
+	assert.False(t, storedTokenMatches("token", true, "token"))
+	assert.True(t, storedTokenMatches("token", false, "token"))
+
+	SetTokenKey([]byte("key2"))
+	assert.NotEqual(t, hashed, hashToken("token"))
+}
@@ -0,0 +1,167 @@
+package calculated
+
+import "github.com/pkg/errors"
+
+// Aggregation describes how a question's values across entities roll up to
+// the parent
+type Aggregation struct {
+	Method AggregationMethod
+	// Weight is the question each entity's value is weighted by, only used
+	// by WeightedAverage
+	Weight QID
+}
+
+// AggregationMethod combines the values of a question across entities
+type AggregationMethod string
+
+const (
+	Sum             AggregationMethod = "Sum"
+	Average         AggregationMethod = "Average"
+	WeightedAverage AggregationMethod = "Weighted Average"
+)
+
+var (
+	ErrAggregationMethod  = errors.New("invalid aggregation method")
+	ErrMissingWeight      = errors.New("weighted average missing weight question")
+	ErrMissingAggregation = errors.New("ratio question missing aggregation")
+)
+
+// Aggregate consolidates the answers of several entities (facilities,
+// subsidiaries, ...) into the answers of their parent.  Formulas are
+// evaluated per entity before rolling up, then each question is combined
+// using its aggregation, defaulting to Sum.  Summing ratios is meaningless,
+// so calculated questions dividing at the top level must have an aggregation,
+// else ErrMissingAggregation.  Weights are taken from the entity's evaluated
+// values, so a weight may itself be a calculated question.  Averages only
+// include the entities which answered the question, a calculated question
+// being answered when any question of its formula is.
+func Aggregate(
+	entities []map[QID]float64,
+	expressions map[QID]Expr,
+	aggregations map[QID]Aggregation,
+) (map[QID]float64, error) {
+
+	for question, expr := range expressions {
+		if _, ok := aggregations[question]; ok {
+			continue
+		}
+		if divides(expr, expressions, map[QID]struct{}{}) {
+			return map[QID]float64{}, errors.Wrapf(ErrMissingAggregation, "%s", question)
+		}
+	}
+
+	evaluated := make([]map[QID]float64, 0, len(entities))
+	questions := map[QID]struct{}{}
+	for _, entity := range entities {
+		values, err := EvalAll(expressions, entity)
+		if err != nil {
+			return map[QID]float64{}, err
+		}
+		for question := range values {
+			questions[question] = struct{}{}
+		}
+		evaluated = append(evaluated, values)
+	}
+
+	result := make(map[QID]float64, len(questions))
+	for question := range questions {
+		aggregation, ok := aggregations[question]
+		if !ok {
+			aggregation = Aggregation{Method: Sum}
+		}
+
+		answered := make([]map[QID]float64, 0, len(entities))
+		for i, entity := range entities {
+			if isAnswered(question, entity, expressions) {
+				answered = append(answered, evaluated[i])
+			}
+		}
+
+		value, err := aggregate(question, aggregation, answered)
+		if err != nil {
+			return map[QID]float64{}, errors.Wrapf(err, "%s", question)
+		}
+		result[question] = value
+	}
+
+	return result, nil
+}
+
+// divides reports whether expression, or the formula of any question it
+// references, divides
+func divides(expression Expr, expressions map[QID]Expr, visited map[QID]struct{}) bool {
+	switch expression := expression.(type) {
+	case OpExpr:
+		return expression.Op == Divide ||
+			divides(expression.Left, expressions, visited) ||
+			divides(expression.Right, expressions, visited)
+	case QID:
+		if _, ok := visited[expression]; ok {
+			return false
+		}
+		visited[expression] = struct{}{}
+		referenced, ok := expressions[expression]
+		return ok && divides(referenced, expressions, visited)
+	default:
+		return false
+	}
+}
+
+func isAnswered(question QID, entity map[QID]float64, expressions map[QID]Expr) bool {
+	if _, ok := entity[question]; ok {
+		return true
+	}
+	expr, ok := expressions[question]
+	if !ok {
+		return false
+	}
+	for _, q := range Questions(expr) {
+		if _, ok := entity[q]; ok {
+			return true
+		}
+	}
+	return false
+}
+
+// aggregate combines question across the entities which answered it
+func aggregate(
+	question QID,
+	aggregation Aggregation,
+	entities []map[QID]float64,
+) (float64, error) {
+
+	switch aggregation.Method {
+	case Sum:
+		total := 0.0
+		for _, entity := range entities {
+			total += entity[question]
+		}
+		return total, nil
+	case Average:
+		if len(entities) == 0 {
+			return 0, nil
+		}
+		total := 0.0
+		for _, entity := range entities {
+			total += entity[question]
+		}
+		return total / float64(len(entities)), nil
+	case WeightedAverage:
+		if aggregation.Weight == "" {
+			return 0, ErrMissingWeight
+		}
+		total := 0.0
+		totalWeight := 0.0
+		for _, entity := range entities {
+			weight := entity[aggregation.Weight]
+			total += entity[question] * weight
+			totalWeight += weight
+		}
+		if totalWeight == 0.0 {
+			return 0, nil
+		}
+		return total / totalWeight, nil
+	default:
+		return 0, ErrAggregationMethod
+	}
+}
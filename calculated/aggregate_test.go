@@ -0,0 +1,185 @@
+package calculated
+
+import (
+	"testing"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAggregate_Empty(t *testing.T) {
+	result, err := Aggregate([]map[QID]float64{}, map[QID]Expr{}, map[QID]Aggregation{})
+
+	assert.NoError(t, err)
+	assert.Equal(t, map[QID]float64{}, result)
+}
+
+func TestAggregate_DefaultsToSum(t *testing.T) {
+	result, err := Aggregate([]map[QID]float64{
+		{
+			"employees": 10,
+			"revenue":   1000,
+		},
+		{
+			"employees": 5,
+		},
+	}, map[QID]Expr{}, map[QID]Aggregation{})
+
+	assert.NoError(t, err)
+	assert.Equal(t, map[QID]float64{
+		"employees": 15,
+		"revenue":   1000,
+	}, result)
+}
+
+func TestAggregate_Average(t *testing.T) {
+	result, err := Aggregate([]map[QID]float64{
+		{"score": 10},
+		{"score": 20},
+		{},
+	}, map[QID]Expr{}, map[QID]Aggregation{
+		"score": {Method: Average},
+	})
+
+	assert.NoError(t, err)
+	assert.Equal(t, map[QID]float64{"score": 15}, result)
+}
+
+func TestAggregate_AverageCalculated(t *testing.T) {
+	result, err := Aggregate([]map[QID]float64{
+		{"fullTime": 10, "partTime": 10},
+		{"fullTime": 30},
+		{"other": 1},
+	}, map[QID]Expr{
+		"total": OpExpr{Op: Add, Left: QID("fullTime"), Right: QID("partTime")},
+	}, map[QID]Aggregation{
+		"total": {Method: Average},
+	})
+
+	assert.NoError(t, err)
+	assert.Equal(t, 25.0, result["total"])
+}
+
+func TestAggregate_RatioMissingAggregation(t *testing.T) {
+	_, err := Aggregate([]map[QID]float64{
+		{"fullTime": 30, "total": 40},
+	}, map[QID]Expr{
+		"percentFullTime": OpExpr{Op: Divide, Left: QID("fullTime"), Right: QID("total")},
+	}, map[QID]Aggregation{})
+
+	assert.Equal(t, ErrMissingAggregation, errors.Cause(err))
+}
+
+func TestAggregate_NestedRatioMissingAggregation(t *testing.T) {
+	_, err := Aggregate([]map[QID]float64{
+		{"fullTime": 30, "total": 60, "hundred": 100},
+		{"fullTime": 20, "total": 40, "hundred": 100},
+	}, map[QID]Expr{
+		"ratio": OpExpr{Op: Divide, Left: QID("fullTime"), Right: QID("total")},
+		"pct":   OpExpr{Op: Multiply, Left: QID("ratio"), Right: QID("hundred")},
+	}, map[QID]Aggregation{
+		"ratio": {Method: WeightedAverage, Weight: "total"},
+	})
+	assert.Equal(t, ErrMissingAggregation, errors.Cause(err))
+
+	_, err = Aggregate([]map[QID]float64{
+		{"fullTime": 30, "total": 60, "hundred": 100},
+	}, map[QID]Expr{
+		"pct": OpExpr{
+			Op:    Multiply,
+			Left:  OpExpr{Op: Divide, Left: QID("fullTime"), Right: QID("total")},
+			Right: QID("hundred"),
+		},
+	}, map[QID]Aggregation{})
+	assert.Equal(t, ErrMissingAggregation, errors.Cause(err))
+}
+
+func TestAggregate_WeightedAverage(t *testing.T) {
+	result, err := Aggregate([]map[QID]float64{
+		{
+			"employees":      30,
+			"percentWomen":   50,
+			"percentRenewed": 10,
+		},
+		{
+			"employees":      10,
+			"percentWomen":   10,
+			"percentRenewed": 90,
+		},
+	}, map[QID]Expr{}, map[QID]Aggregation{
+		"percentWomen":   {Method: WeightedAverage, Weight: "employees"},
+		"percentRenewed": {Method: Average},
+	})
+
+	assert.NoError(t, err)
+	assert.Equal(t, map[QID]float64{
+		"employees":      40,
+		"percentWomen":   40,
+		"percentRenewed": 50,
+	}, result)
+}
+
+func TestAggregate_WeightedAverageZeroWeight(t *testing.T) {
+	result, err := Aggregate([]map[QID]float64{
+		{"percentWomen": 50},
+	}, map[QID]Expr{}, map[QID]Aggregation{
+		"percentWomen": {Method: WeightedAverage, Weight: "employees"},
+	})
+
+	assert.NoError(t, err)
+	assert.Equal(t, map[QID]float64{"percentWomen": 0}, result)
+}
+
+func TestAggregate_CalculatedPerEntity(t *testing.T) {
+	result, err := Aggregate([]map[QID]float64{
+		{
+			"fullTime": 30,
+			"partTime": 10,
+		},
+		{
+			"fullTime": 5,
+			"partTime": 5,
+		},
+	}, map[QID]Expr{
+		"total": OpExpr{
+			Op:    Add,
+			Left:  QID("fullTime"),
+			Right: QID("partTime"),
+		},
+		"percentFullTime": OpExpr{
+			Op:    Divide,
+			Left:  QID("fullTime"),
+			Right: OpExpr{Op: Add, Left: QID("fullTime"), Right: QID("partTime")},
+		},
+	}, map[QID]Aggregation{
+		"percentFullTime": {Method: WeightedAverage, Weight: "total"},
+	})
+
+	assert.NoError(t, err)
+	assert.Equal(t, map[QID]float64{
+		"fullTime":        35,
+		"partTime":        15,
+		"total":           50,
+		"percentFullTime": 0.7,
+	}, result)
+}
+
+func TestAggregate_MissingWeight(t *testing.T) {
+	_, err := Aggregate([]map[QID]float64{
+		{"percentWomen": 50},
+	}, map[QID]Expr{}, map[QID]Aggregation{
+		"percentWomen": {Method: WeightedAverage},
+	})
+
+	assert.Equal(t, ErrMissingWeight, errors.Cause(err))
+}
+
+func TestAggregate_InvalidMethod(t *testing.T) {
+	_, err := Aggregate([]map[QID]float64{
+		{"percentWomen": 50},
+	}, map[QID]Expr{}, map[QID]Aggregation{
+		"percentWomen": {Method: "Median"},
+	})
+
+	assert.Equal(t, ErrAggregationMethod, errors.Cause(err))
+}
@@ -1,9 +1,15 @@
 package calculated
 
-import "github.com/pkg/errors"
+import (
+	"fmt"
 
+	"github.com/pkg/errors"
+)
+
+// QID identifies a question
 type QID string
 
+// Expr is a node of an expanded formula tree, either a QID leaf or an OpExpr
 type Expr interface {
 	isExpr()
 }
@@ -13,24 +19,30 @@ func (OpExpr) isExpr() {}
 
 // OpDef is a composition of two questions and how to compute them
 type OpDef struct {
-	op    Op
-	left  QID
-	right QID
+	Op    Op
+	Left  QID
+	Right QID
 }
 
+// OpExpr is an OpDef whose operands have been expanded into their own formulas
 type OpExpr struct {
-	op    Op
-	left  Expr
-	right Expr
+	Op    Op
+	Left  Expr
+	Right Expr
+}
+
+func (e OpExpr) String() string {
+	return fmt.Sprintf("(%v %v %v)", e.Left, e.Op, e.Right)
 }
 
+// Op is an arithmetic operator
 type Op string
 
 const (
 	Add      Op = "+"
-	Subtract    = "-"
-	Multiply    = "*"
-	Divide      = "/"
+	Subtract Op = "-"
+	Multiply Op = "*"
+	Divide   Op = "/"
 )
 
 var (
@@ -38,7 +50,9 @@ var (
 	ErrRecursiveFormula = errors.New("recursive formula question")
 )
 
-func eval(
+// Eval computes expression from the supplied question values.  Questions
+// missing from questions evaluate to 0, as does division by 0.
+func Eval(
 	expression Expr,
 	questions map[QID]float64,
 ) (float64, error) {
@@ -57,16 +71,16 @@ func evalOp(
 	questions map[QID]float64,
 ) (float64, error) {
 
-	leftVal, err := eval(op.left, questions)
+	leftVal, err := Eval(op.Left, questions)
 	if err != nil {
 		return 0, err
 	}
-	rightVal, err := eval(op.right, questions)
+	rightVal, err := Eval(op.Right, questions)
 	if err != nil {
 		return 0, err
 	}
 
-	switch op.op {
+	switch op.Op {
 	case Add:
 		return leftVal + rightVal, nil
 	case Subtract:
@@ -84,7 +98,49 @@ func evalOp(
 	}
 }
 
-func expand(
+// EvalAll computes every expanded formula against the supplied question
+// values.  The result holds the supplied values along with the calculated
+// ones, calculated values taking precedence.
+func EvalAll(
+	expressions map[QID]Expr,
+	questions map[QID]float64,
+) (map[QID]float64, error) {
+
+	result := make(map[QID]float64, len(questions)+len(expressions))
+	for question, value := range questions {
+		result[question] = value
+	}
+
+	for question, expr := range expressions {
+		value, err := Eval(expr, questions)
+		if err != nil {
+			return map[QID]float64{}, errors.Wrapf(err, "%s", question)
+		}
+		result[question] = value
+	}
+
+	return result, nil
+}
+
+// Calculate expands the formula definitions and evaluates them against the
+// supplied question values.  See EvalAll.
+func Calculate(
+	list map[QID]OpDef,
+	questions map[QID]float64,
+) (map[QID]float64, error) {
+
+	expressions, err := Expand(list)
+	if err != nil {
+		return map[QID]float64{}, err
+	}
+
+	return EvalAll(expressions, questions)
+}
+
+// Expand replaces each operand referencing another formula question with that
+// question's formula, so every resulting tree has plain questions as leaves.
+// Returns ErrRecursiveFormula if a formula references itself.
+func Expand(
 	list map[QID]OpDef,
 ) (map[QID]Expr, error) {
 
@@ -118,16 +174,40 @@ func expandOpDef(
 	var err error
 	visited[id] = struct{}{}
 	result := OpExpr{}
-	result.op = found.op
-	result.left, err = expandOpDef(found.left, list, visited)
+	result.Op = found.Op
+	result.Left, err = expandOpDef(found.Left, list, visited)
 	if err != nil {
 		return nil, err
 	}
 
-	result.right, err = expandOpDef(found.right, list, visited)
+	result.Right, err = expandOpDef(found.Right, list, visited)
 	if err != nil {
 		return nil, err
 	}
 
+	// visited only tracks the current path, so a question may be referenced more than once
+	delete(visited, id)
 	return result, nil
 }
+
+// Questions returns the plain questions referenced by expression, in order of
+// appearance and without duplicates
+func Questions(expression Expr) []QID {
+	result := []QID{}
+	seen := map[QID]struct{}{}
+	var walk func(Expr)
+	walk = func(expression Expr) {
+		switch expression := expression.(type) {
+		case OpExpr:
+			walk(expression.Left)
+			walk(expression.Right)
+		case QID:
+			if _, ok := seen[expression]; !ok {
+				seen[expression] = struct{}{}
+				result = append(result, expression)
+			}
+		}
+	}
+	walk(expression)
+	return result
+}
@@ -3,13 +3,14 @@ package calculated
 import (
 	"testing"
 
+	"github.com/pkg/errors"
 	"github.com/stretchr/testify/assert"
 )
 
 func TestEval(t *testing.T) {
 	expanded := OpExpr{
-		left:  QID("Q1"),
-		right: QID("Q2"),
+		Left:  QID("Q1"),
+		Right: QID("Q2"),
 	}
 
 	assessment := map[QID]float64{
@@ -17,37 +18,37 @@ func TestEval(t *testing.T) {
 		"Q2": 2.5,
 	}
 
-	expanded.op = Add
-	addResult, addErr := eval(expanded, assessment)
+	expanded.Op = Add
+	addResult, addErr := Eval(expanded, assessment)
 	assert.NoError(t, addErr)
 	assert.Equal(t, 7.5, addResult)
 
-	expanded.op = Subtract
-	subtractResult, subtractErr := eval(expanded, assessment)
+	expanded.Op = Subtract
+	subtractResult, subtractErr := Eval(expanded, assessment)
 	assert.NoError(t, subtractErr)
 	assert.Equal(t, 2.5, subtractResult)
 
-	expanded.op = Multiply
-	multiplyResult, multiplyErr := eval(expanded, assessment)
+	expanded.Op = Multiply
+	multiplyResult, multiplyErr := Eval(expanded, assessment)
 	assert.NoError(t, multiplyErr)
 	assert.Equal(t, 12.5, multiplyResult)
 
-	expanded.op = Divide
-	divideResult, divideErr := eval(expanded, assessment)
+	expanded.Op = Divide
+	divideResult, divideErr := Eval(expanded, assessment)
 	assert.NoError(t, divideErr)
 	assert.Equal(t, 2.0, divideResult)
 
-	expanded.op = "!"
-	unknownResult, unknownErr := eval(expanded, assessment)
+	expanded.Op = "!"
+	unknownResult, unknownErr := Eval(expanded, assessment)
 	assert.NoError(t, unknownErr)
 	assert.Equal(t, 0.0, unknownResult)
 }
 
 func TestEval_DivideByZero(t *testing.T) {
-	divideResult, divideErr := eval(OpExpr{
-		op:    Divide,
-		left:  QID("Q1"),
-		right: QID("Q2"),
+	divideResult, divideErr := Eval(OpExpr{
+		Op:    Divide,
+		Left:  QID("Q1"),
+		Right: QID("Q2"),
 	}, map[QID]float64{
 		"Q1": 5.0,
 		"Q2": 0,
@@ -57,17 +58,17 @@ func TestEval_DivideByZero(t *testing.T) {
 }
 
 func TestEval_Descendants(t *testing.T) {
-	result, err := eval(OpExpr{
-		op: Add,
-		left: OpExpr{
-			op:    Add,
-			left:  QID("Q1"),
-			right: QID("Q2"),
-		},
-		right: OpExpr{
-			op:    Add,
-			left:  QID("Q2"),
-			right: QID("Q3"),
+	result, err := Eval(OpExpr{
+		Op: Add,
+		Left: OpExpr{
+			Op:    Add,
+			Left:  QID("Q1"),
+			Right: QID("Q2"),
+		},
+		Right: OpExpr{
+			Op:    Add,
+			Left:  QID("Q2"),
+			Right: QID("Q3"),
 		},
 	}, map[QID]float64{
 		"Q1": 5.0,
@@ -79,10 +80,10 @@ func TestEval_Descendants(t *testing.T) {
 }
 
 func TestEval_DescendentsMissing(t *testing.T) {
-	result, err := eval(OpExpr{
-		op:    Add,
-		left:  QID("Q1"),
-		right: QID("Q2"),
+	result, err := Eval(OpExpr{
+		Op:    Add,
+		Left:  QID("Q1"),
+		Right: QID("Q2"),
 	}, map[QID]float64{
 		"Q2": 5.3,
 	})
@@ -91,10 +92,10 @@ func TestEval_DescendentsMissing(t *testing.T) {
 }
 
 func TestEval_InvalidLeft(t *testing.T) {
-	_, err := eval(OpExpr{
-		op:    Add,
-		left:  QID("Q1"),
-		right: nil,
+	_, err := Eval(OpExpr{
+		Op:    Add,
+		Left:  QID("Q1"),
+		Right: nil,
 	}, map[QID]float64{
 		"Q1": 2.5,
 	})
@@ -102,10 +103,10 @@ func TestEval_InvalidLeft(t *testing.T) {
 }
 
 func TestEval_InvalidRight(t *testing.T) {
-	_, err := eval(OpExpr{
-		op:    Add,
-		left:  nil,
-		right: QID("Q1"),
+	_, err := Eval(OpExpr{
+		Op:    Add,
+		Left:  nil,
+		Right: QID("Q1"),
 	}, map[QID]float64{
 		"Q1": 2.5,
 	})
@@ -113,106 +114,218 @@ func TestEval_InvalidRight(t *testing.T) {
 }
 
 func TestExpand_Empty(t *testing.T) {
-	result, err := expand(map[QID]OpDef{})
+	result, err := Expand(map[QID]OpDef{})
 
 	assert.NoError(t, err)
 	assert.Equal(t, map[QID]Expr{}, result)
 }
 
 func TestExpand(t *testing.T) {
-	result, err := expand(map[QID]OpDef{
+	result, err := Expand(map[QID]OpDef{
 		"Q1": OpDef{
-			op:    Add,
-			left:  "Q2",
-			right: "Q3",
+			Op:    Add,
+			Left:  "Q2",
+			Right: "Q3",
 		},
 		"Q4": OpDef{
-			op:    Add,
-			left:  "Q5",
-			right: "Q6",
+			Op:    Add,
+			Left:  "Q5",
+			Right: "Q6",
 		},
 	})
 
 	assert.NoError(t, err)
 	assert.Equal(t, map[QID]Expr{
 		"Q1": OpExpr{
-			op:    Add,
-			left:  QID("Q2"),
-			right: QID("Q3"),
+			Op:    Add,
+			Left:  QID("Q2"),
+			Right: QID("Q3"),
 		},
 		"Q4": OpExpr{
-			op:    Add,
-			left:  QID("Q5"),
-			right: QID("Q6"),
+			Op:    Add,
+			Left:  QID("Q5"),
+			Right: QID("Q6"),
 		},
 	}, result)
 }
 
 func TestExpand_Deep(t *testing.T) {
-	result, err := expand(map[QID]OpDef{
+	result, err := Expand(map[QID]OpDef{
 		"Q3": OpDef{
-			op:    Add,
-			left:  "Q1",
-			right: "Q2",
+			Op:    Add,
+			Left:  "Q1",
+			Right: "Q2",
 		},
 		"Q4": OpDef{
-			op:    Add,
-			left:  "Q2",
-			right: "Q1",
+			Op:    Add,
+			Left:  "Q2",
+			Right: "Q1",
 		},
 		"Q6": OpDef{
-			op:    Add,
-			left:  "Q3",
-			right: "Q4",
+			Op:    Add,
+			Left:  "Q3",
+			Right: "Q4",
 		},
 	})
 
 	assert.NoError(t, err)
 	assert.Equal(t, map[QID]Expr{
 		"Q3": OpExpr{
-			op:    Add,
-			left:  QID("Q1"),
-			right: QID("Q2"),
+			Op:    Add,
+			Left:  QID("Q1"),
+			Right: QID("Q2"),
 		},
 		"Q4": OpExpr{
-			op:    Add,
-			left:  QID("Q2"),
-			right: QID("Q1"),
+			Op:    Add,
+			Left:  QID("Q2"),
+			Right: QID("Q1"),
 		},
 		"Q6": OpExpr{
-			op: Add,
-			left: OpExpr{
-				op:    Add,
-				left:  QID("Q1"),
-				right: QID("Q2"),
+			Op: Add,
+			Left: OpExpr{
+				Op:    Add,
+				Left:  QID("Q1"),
+				Right: QID("Q2"),
 			},
-			right: OpExpr{
-				op:    Add,
-				left:  QID("Q2"),
-				right: QID("Q1"),
+			Right: OpExpr{
+				Op:    Add,
+				Left:  QID("Q2"),
+				Right: QID("Q1"),
 			},
 		},
 	}, result)
 }
 
 func TestExpanded_Infinite(t *testing.T) {
-	_, err := expand(map[QID]OpDef{
+	_, err := Expand(map[QID]OpDef{
 		"Q1": OpDef{
-			op:    Add,
-			left:  "Q1",
-			right: "Q2",
+			Op:    Add,
+			Left:  "Q1",
+			Right: "Q2",
 		},
 	})
 
 	assert.Error(t, err)
 
-	_, err = expand(map[QID]OpDef{
+	_, err = Expand(map[QID]OpDef{
 		"Q1": OpDef{
-			op:    Add,
-			left:  QID("Q2"),
-			right: QID("Q1"),
+			Op:    Add,
+			Left:  QID("Q2"),
+			Right: QID("Q1"),
 		},
 	})
 
 	assert.Error(t, err)
 }
+
+func TestEvalAll(t *testing.T) {
+	result, err := EvalAll(map[QID]Expr{
+		"Q3": OpExpr{
+			Op:    Add,
+			Left:  QID("Q1"),
+			Right: QID("Q2"),
+		},
+		"Q4": OpExpr{
+			Op:    Multiply,
+			Left:  QID("Q1"),
+			Right: QID("Q2"),
+		},
+	}, map[QID]float64{
+		"Q1": 2,
+		"Q2": 3,
+	})
+
+	assert.NoError(t, err)
+	assert.Equal(t, map[QID]float64{
+		"Q1": 2,
+		"Q2": 3,
+		"Q3": 5,
+		"Q4": 6,
+	}, result)
+}
+
+func TestEvalAll_Invalid(t *testing.T) {
+	_, err := EvalAll(map[QID]Expr{
+		"Q3": OpExpr{
+			Op:   Add,
+			Left: QID("Q1"),
+		},
+	}, map[QID]float64{})
+
+	assert.Equal(t, ErrOperandType, errors.Cause(err))
+}
+
+func TestCalculate(t *testing.T) {
+	result, err := Calculate(map[QID]OpDef{
+		"Q3": OpDef{
+			Op:    Add,
+			Left:  "Q1",
+			Right: "Q2",
+		},
+		"Q4": OpDef{
+			Op:    Divide,
+			Left:  "Q3",
+			Right: "Q2",
+		},
+	}, map[QID]float64{
+		"Q1": 2,
+		"Q2": 4,
+	})
+
+	assert.NoError(t, err)
+	assert.Equal(t, map[QID]float64{
+		"Q1": 2,
+		"Q2": 4,
+		"Q3": 6,
+		"Q4": 1.5,
+	}, result)
+}
+
+func TestCalculate_Recursive(t *testing.T) {
+	_, err := Calculate(map[QID]OpDef{
+		"Q1": OpDef{
+			Op:    Add,
+			Left:  "Q1",
+			Right: "Q2",
+		},
+	}, map[QID]float64{})
+
+	assert.Equal(t, ErrRecursiveFormula, errors.Cause(err))
+}
+
+func TestCalculate_RepeatedQuestion(t *testing.T) {
+	result, err := Calculate(map[QID]OpDef{
+		"total": OpDef{
+			Op:    Add,
+			Left:  "Q1",
+			Right: "Q2",
+		},
+		"square": OpDef{
+			Op:    Multiply,
+			Left:  "total",
+			Right: "total",
+		},
+	}, map[QID]float64{
+		"Q1": 1,
+		"Q2": 2,
+	})
+
+	assert.NoError(t, err)
+	assert.Equal(t, 9.0, result["square"])
+}
+
+func TestQuestions(t *testing.T) {
+	assert.Equal(t, []QID{"Q1", "Q2", "Q3"}, Questions(OpExpr{
+		Op: Add,
+		Left: OpExpr{
+			Op:    Add,
+			Left:  QID("Q1"),
+			Right: QID("Q2"),
+		},
+		Right: OpExpr{
+			Op:    Add,
+			Left:  QID("Q2"),
+			Right: QID("Q3"),
+		},
+	}))
+}
@@ -0,0 +1,76 @@
+package calculated_test
+
+import (
+	"fmt"
+
+	"github.com/thematthopkins/impact-go/calculated"
+)
+
+func ExampleExpand() {
+	expressions, err := calculated.Expand(map[calculated.QID]calculated.OpDef{
+		"totalEmployees": {
+			Op:    calculated.Add,
+			Left:  "fullTimeEmployees",
+			Right: "partTimeEmployees",
+		},
+		"percentFullTime": {
+			Op:    calculated.Divide,
+			Left:  "fullTimeEmployees",
+			Right: "totalEmployees",
+		},
+	})
+	if err != nil {
+		panic(err)
+	}
+
+	fmt.Println(expressions["percentFullTime"])
+	// Output: (fullTimeEmployees / (fullTimeEmployees + partTimeEmployees))
+}
+
+func ExampleEvalAll() {
+	expressions, err := calculated.Expand(map[calculated.QID]calculated.OpDef{
+		"totalEmployees": {
+			Op:    calculated.Add,
+			Left:  "fullTimeEmployees",
+			Right: "partTimeEmployees",
+		},
+	})
+	if err != nil {
+		panic(err)
+	}
+
+	values, err := calculated.EvalAll(expressions, map[calculated.QID]float64{
+		"fullTimeEmployees": 30,
+		"partTimeEmployees": 12,
+	})
+	if err != nil {
+		panic(err)
+	}
+
+	fmt.Println(values["totalEmployees"])
+	// Output: 42
+}
+
+func ExampleOpExpr() {
+	expressions, err := calculated.Expand(map[calculated.QID]calculated.OpDef{
+		"revenuePerEmployee": {
+			Op:    calculated.Divide,
+			Left:  "revenue",
+			Right: "employees",
+		},
+	})
+	if err != nil {
+		panic(err)
+	}
+
+	switch expr := expressions["revenuePerEmployee"].(type) {
+	case calculated.OpExpr:
+		fmt.Println(expr.Op, expr.Left, expr.Right)
+	case calculated.QID:
+		fmt.Println(expr)
+	}
+	fmt.Println(calculated.Questions(expressions["revenuePerEmployee"]))
+	// Output:
+	// / revenue employees
+	// [revenue employees]
+}
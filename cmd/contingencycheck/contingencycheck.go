@@ -0,0 +1,53 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+
+	"github.com/thematthopkins/impact-go/contingency"
+)
+
+type questionBank struct {
+	Definitions  contingency.Definitions
+	AnswerValues map[contingency.QuestionSfid][]contingency.AnswerValueSfid
+}
+
+// contingencycheck reads a question bank as JSON from the file given as the
+// only argument, or stdin, and lists every problem found in its contingencies.
+// Exits with status 1 when there are problems.
+func main() {
+	flag.Parse()
+
+	var input io.Reader = os.Stdin
+	if flag.NArg() > 0 {
+		file, err := os.Open(flag.Arg(0))
+		if err != nil {
+			log.Fatal(err)
+		}
+		defer file.Close()
+		input = file
+	}
+
+	var bank questionBank
+	err := json.NewDecoder(input).Decode(&bank)
+	if err != nil {
+		log.Fatal(fmt.Sprintf("failed to read question bank: %v", err))
+	}
+
+	problems, err := contingency.Analyze(bank.Definitions, bank.AnswerValues)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	for _, problem := range problems {
+		fmt.Println(problem)
+	}
+
+	if len(problems) > 0 {
+		os.Exit(1)
+	}
+}
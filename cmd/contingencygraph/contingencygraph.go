@@ -0,0 +1,54 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+
+	"github.com/thematthopkins/impact-go/contingency"
+)
+
+// contingencygraph reads contingency.Definitions as JSON from the file given
+// as the only argument, or stdin, and writes the dependency graph to stdout
+func main() {
+	format := flag.String("format", "dot", "output format, dot or json")
+	flag.Parse()
+
+	var input io.Reader = os.Stdin
+	if flag.NArg() > 0 {
+		file, err := os.Open(flag.Arg(0))
+		if err != nil {
+			log.Fatal(err)
+		}
+		defer file.Close()
+		input = file
+	}
+
+	var defs contingency.Definitions
+	err := json.NewDecoder(input).Decode(&defs)
+	if err != nil {
+		log.Fatal(fmt.Sprintf("failed to read contingency definitions: %v", err))
+	}
+
+	graph, err := contingency.NewGraph(defs)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	switch *format {
+	case "dot":
+		err = graph.WriteDOT(os.Stdout)
+	case "json":
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+		err = encoder.Encode(graph)
+	default:
+		log.Fatal(fmt.Sprintf("unknown format: %v", *format))
+	}
+	if err != nil {
+		log.Fatal(err)
+	}
+}
@@ -1,14 +1,21 @@
 package main
 
 import (
+	"context"
 	"database/sql"
+	"expvar"
 	"fmt"
 	"log"
 	"net/http"
-	"net/http/httputil"
 	"net/url"
 	"os"
+	"os/signal"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
 
+	"github.com/thematthopkins/impact-go/auth"
 	"github.com/thematthopkins/impact-go/routes"
 
 	_ "github.com/lib/pq"
@@ -27,24 +34,95 @@ func main() {
 		panic(err)
 	}
 
-	http.Handle("/", httputil.NewSingleHostReverseProxy(phpURL))
+	tokenKey := os.Getenv("TOKEN_HASH_KEY")
+	if tokenKey == "" {
+		log.Fatal("TOKEN_HASH_KEY must be set")
+	}
+	auth.SetTokenKey([]byte(tokenKey))
+
+	// closed on shutdown, once requests have finished
+	stop := make(chan struct{})
+	var background sync.WaitGroup
+
+	// TOKEN_CACHE_TTL of 0 disables caching validated tokens
+	if ttl := os.Getenv("TOKEN_CACHE_TTL"); ttl != "" {
+		cacheTTL, err := time.ParseDuration(ttl)
+		if err != nil {
+			log.Fatal(fmt.Sprintf("invalid TOKEN_CACHE_TTL %v: %v", ttl, err))
+		}
+		auth.ValidationCache = auth.NewTokenCache(10000, cacheTTL)
+	}
+	go func() {
+		err := auth.ValidationCache.ListenForRevocations(databaseURL, stop)
+		if err != nil {
+			log.Fatal(err)
+		}
+	}()
+	expvar.Publish("tokenCache", expvar.Func(func() interface{} { return auth.ValidationCache.Stats() }))
+
+	// TRUSTED_PROXIES are the comma separated networks of the load balancers in front of the service
+	if proxies := os.Getenv("TRUSTED_PROXIES"); proxies != "" {
+		err = auth.SetTrustedProxies(strings.Split(proxies, ","))
+		if err != nil {
+			log.Fatal(err)
+		}
+	}
+
+	if os.Getenv("RATE_LIMIT_STORE") == "postgres" {
+		auth.TokenLimiter = auth.NewTokenLimiter(auth.NewPostgresAttemptStore(db))
+	}
+
+	background.Add(2)
+	go func() {
+		defer background.Done()
+		auth.Activity.Run(db, 30*time.Second, stop)
+	}()
+	sweeper := auth.NewSweeper(1000, 10*time.Minute)
+	go func() {
+		defer background.Done()
+		sweeper.Run(db, stop)
+	}()
+	expvar.Publish("sweeper", expvar.Func(func() interface{} { return sweeper.Stats() }))
+
+	routes.HandleProxy(phpURL, db)
 	routes.Handle(http.DefaultServeMux, db)
 
 	https := os.Getenv("ENABLE_HTTPS") != ""
-
+	port := ":8080"
 	if https {
-		fmt.Println("serving https")
-		port := ":4443"
-		err = http.ListenAndServeTLS(port, "ssl/cert.pem", "ssl/cert.pem", nil)
+		port = ":4443"
+	}
+	server := &http.Server{Addr: port}
+
+	shutdown := make(chan struct{})
+	go func() {
+		defer close(shutdown)
+		signals := make(chan os.Signal, 1)
+		signal.Notify(signals, os.Interrupt, syscall.SIGTERM)
+		<-signals
+
+		fmt.Println("shutting down")
+		ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+		defer cancel()
+		err := server.Shutdown(ctx)
 		if err != nil {
-			log.Fatal(fmt.Sprintf("failed to bind to port %v:  %v", port, err))
+			fmt.Println("error shutting down: ", err)
 		}
+	}()
+
+	if https {
+		fmt.Println("serving https")
+		err = server.ListenAndServeTLS("ssl/cert.pem", "ssl/cert.pem")
 	} else {
 		fmt.Println("serving http")
-		port := ":8080"
-		err = http.ListenAndServe(port, nil)
-		if err != nil {
-			log.Fatal(fmt.Sprintf("failed to bind to port %v:  %v", port, err))
-		}
+		err = server.ListenAndServe()
+	}
+	if err != http.ErrServerClosed {
+		log.Fatal(fmt.Sprintf("failed to bind to port %v:  %v", port, err))
 	}
+
+	// once every request has finished, lets the activity tracker flush what they recorded
+	<-shutdown
+	close(stop)
+	background.Wait()
 }
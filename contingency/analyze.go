@@ -0,0 +1,264 @@
+package contingency
+
+import (
+	"fmt"
+	"sort"
+)
+
+//Problem found in the contingency definitions
+type Problem struct {
+	Type     ProblemType
+	Question QuestionSfid
+	//Rules responsible for the problem
+	Rules []Rule
+}
+
+//ProblemType kind of problem
+type ProblemType string
+
+const (
+	//UnreachableQuestion can never be shown
+	UnreachableQuestion ProblemType = "unreachable question"
+	//UnknownQuestion a rule's master question isn't in the question bank
+	UnknownQuestion ProblemType = "unknown question"
+	//UnknownAnswerValue a rule's answer value doesn't belong to its master question
+	UnknownAnswerValue ProblemType = "unknown answer value"
+	//RedundantRule a rule that has no effect, as it's duplicated or implied by the question's other rules
+	//and those of its ancestors
+	RedundantRule ProblemType = "redundant rule"
+)
+
+func (p Problem) String() string {
+	rules := make([]string, len(p.Rules))
+	for i, rule := range p.Rules {
+		rules[i] = rule.String()
+	}
+	return fmt.Sprintf("%s %s: %v", p.Question, p.Type, rules)
+}
+
+//Analyze defs for problems, given the answer values belonging to each question of the bank
+func Analyze(
+	defs Definitions,
+	answerValues map[QuestionSfid][]AnswerValueSfid,
+) ([]Problem, error) {
+
+	engine, err := NewEngine(defs)
+	if err != nil {
+		return []Problem{}, err
+	}
+
+	unreachable := engine.unreachableQuestions()
+
+	result := []Problem{}
+	result = append(result, unknownAnswerValues(defs, answerValues)...)
+	result = append(result, duplicateGoals(defs)...)
+	result = append(result, engine.redundantRules(unreachable)...)
+	result = append(result, unreachable...)
+
+	sort.SliceStable(result, func(i, j int) bool { return result[i].Question < result[j].Question })
+	return result, nil
+}
+
+func unknownAnswerValues(
+	defs Definitions,
+	answerValues map[QuestionSfid][]AnswerValueSfid,
+) []Problem {
+
+	result := []Problem{}
+	for _, rule := range defs.Rules() {
+		if rule.Type == ConditionRule {
+			continue
+		}
+
+		masterAnswerValues, ok := answerValues[rule.Master]
+		if !ok {
+			result = append(result, Problem{Type: UnknownQuestion, Question: rule.Question, Rules: []Rule{rule}})
+			continue
+		}
+
+		if rule.Type == EnablingQuestionRule {
+			continue
+		}
+
+		found := false
+		for _, answerValue := range masterAnswerValues {
+			if answerValue == rule.AnswerValue {
+				found = true
+				break
+			}
+		}
+		if !found {
+			result = append(result, Problem{Type: UnknownAnswerValue, Question: rule.Question, Rules: []Rule{rule}})
+		}
+	}
+	return result
+}
+
+//duplicateGoals finds goals applying the same rule to a question more than once
+func duplicateGoals(defs Definitions) []Problem {
+	counts := map[Rule]int{}
+	for _, goal := range defs.Goals {
+		if goal.MasterQuestion == nil {
+			continue
+		}
+		ruleType := EnablingAnswerValueRule
+		if goal.Disables {
+			ruleType = DisablingAnswerValueRule
+		}
+		for _, q := range goal.Questions {
+			counts[Rule{Question: q, Type: ruleType, Master: *goal.MasterQuestion, AnswerValue: *goal.AnswerValue}]++
+		}
+	}
+
+	rules := []Rule{}
+	for rule, count := range counts {
+		if count > 1 {
+			rules = append(rules, rule)
+		}
+	}
+	sortRules(rules)
+
+	result := []Problem{}
+	for _, rule := range rules {
+		result = append(result, Problem{Type: RedundantRule, Question: rule.Question, Rules: []Rule{rule}})
+	}
+	return result
+}
+
+//redundantRules finds rules of reachable questions which don't change when they're shown, as q's
+//other rules, or those of its ancestors, already require or exclude the same responses
+func (e *Engine) redundantRules(unreachable []Problem) []Problem {
+	skip := map[QuestionSfid]struct{}{}
+	for _, problem := range unreachable {
+		skip[problem.Question] = struct{}{}
+	}
+
+	result := []Problem{}
+	for _, q := range e.sortedOwnQuestions() {
+		deps, ok := e.direct[q]
+		if _, unreachable := skip[q]; unreachable || !ok {
+			continue
+		}
+
+		rules := directRules(q, deps)
+		sortRules(rules)
+		for _, rule := range rules {
+			without := ownCondition(withoutRule(deps, rule), true, e.ownConditions[q])
+			if e.equivalent(q, e.own[q], without) {
+				result = append(result, Problem{Type: RedundantRule, Question: q, Rules: []Rule{rule}})
+			}
+		}
+	}
+	return result
+}
+
+//equivalent if q's visibility is the same with own or other as its own condition
+func (e *Engine) equivalent(q QuestionSfid, own Condition, other Condition) bool {
+	for _, pair := range [][2]Condition{{own, other}, {other, own}} {
+		terms := append(e.inheritedTerms(q),
+			term{owner: q, condition: pair[0]},
+			term{owner: q, condition: pair[1], negated: true},
+		)
+		if differs, _ := satisfiable(terms); differs {
+			return false
+		}
+	}
+	return true
+}
+
+//unreachableQuestions finds questions no responses can show, their own rules and those of their
+//ancestors contradicting each other
+func (e *Engine) unreachableQuestions() []Problem {
+	result := []Problem{}
+	for _, q := range e.sortedOwnQuestions() {
+		terms := append([]term{{owner: q, condition: e.own[q]}}, e.inheritedTerms(q)...)
+		reachable, conflicts := satisfiable(terms)
+		if !reachable {
+			result = append(result, Problem{Type: UnreachableQuestion, Question: q, Rules: e.conflictRules(conflicts)})
+		}
+	}
+	return result
+}
+
+//inheritedTerms the own condition of each of q's ancestors
+func (e *Engine) inheritedTerms(q QuestionSfid) []term {
+	result := []term{}
+	for _, ancestor := range sortedQuestions(e.closures[q]) {
+		if condition, ok := e.own[ancestor]; ok {
+			result = append(result, term{owner: ancestor, condition: condition})
+		}
+	}
+	return result
+}
+
+//conflictRules the rules defining each of conflicts, condition rules for those not coming from
+//a goal or enabling question
+func (e *Engine) conflictRules(conflicts []term) []Rule {
+	rules := map[Rule]struct{}{}
+	for _, conflict := range conflicts {
+		rules[e.literalRule(conflict)] = struct{}{}
+	}
+
+	result := make([]Rule, 0, len(rules))
+	for rule := range rules {
+		result = append(result, rule)
+	}
+	sortRules(result)
+	return result
+}
+
+func (e *Engine) literalRule(literal term) Rule {
+	master, _ := literalQuestion(literal.condition)
+	deps := e.direct[literal.owner]
+
+	if selected, ok := literal.condition.(AnswerSelected); ok {
+		answerValues := deps.EnablingAnswerValues
+		ruleType := EnablingAnswerValueRule
+		if literal.negated {
+			answerValues = deps.DisablingAnswerValues
+			ruleType = DisablingAnswerValueRule
+		}
+		if _, ok := answerValues[master][selected.AnswerValue]; ok {
+			return Rule{Question: literal.owner, Type: ruleType, Master: master, AnswerValue: selected.AnswerValue}
+		}
+	} else if !literal.negated {
+		for threshold := range deps.EnablingQuestions[master] {
+			if threshold.condition(master) == literal.condition {
+				return Rule{Question: literal.owner, Type: EnablingQuestionRule, Master: master, Threshold: threshold}
+			}
+		}
+	}
+
+	return Rule{Question: literal.owner, Type: ConditionRule, Master: master}
+}
+
+//withoutRule a copy of deps, less rule
+func withoutRule(deps questionDependencies, rule Rule) questionDependencies {
+	result := questionDependencies{
+		DisablingAnswerValues: AnswerDependencies{},
+		EnablingAnswerValues:  AnswerDependencies{},
+		EnablingQuestions:     QuestionDependencies{},
+	}
+	for _, r := range directRules(rule.Question, deps) {
+		if r == rule {
+			continue
+		}
+		switch r.Type {
+		case EnablingAnswerValueRule:
+			addAnswerValues(result.EnablingAnswerValues, r.Master, AnswerValues{r.AnswerValue: struct{}{}})
+		case DisablingAnswerValueRule:
+			addAnswerValues(result.DisablingAnswerValues, r.Master, AnswerValues{r.AnswerValue: struct{}{}})
+		case EnablingQuestionRule:
+			addThresholds(result.EnablingQuestions, r.Master, Thresholds{r.Threshold: struct{}{}})
+		}
+	}
+	return result
+}
+
+func (e *Engine) sortedOwnQuestions() []QuestionSfid {
+	questions := map[QuestionSfid]struct{}{}
+	for q := range e.own {
+		questions[q] = struct{}{}
+	}
+	return sortedQuestions(questions)
+}
@@ -0,0 +1,411 @@
+package contingency
+
+import (
+	"testing"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAnalyze_NoProblems(t *testing.T) {
+	problems, err := Analyze(Definitions{
+		Goals: []Goal{
+			{
+				MasterQuestion: questionPtr("q0"),
+				AnswerValue:    answerValuePtr("yes"),
+				Questions:      []QuestionSfid{"q1"},
+			},
+		},
+		EnablingQuestions: map[QuestionSfid][]EnablingQuestion{
+			"q2": {{Question: "q1"}},
+		},
+	}, map[QuestionSfid][]AnswerValueSfid{
+		"q0": {"yes", "no"},
+		"q1": {},
+	})
+
+	assert.NoError(t, err)
+	assert.Equal(t, []Problem{}, problems)
+}
+
+func TestAnalyze_UnknownAnswerValue(t *testing.T) {
+	problems, err := Analyze(Definitions{
+		Goals: []Goal{
+			{
+				MasterQuestion: questionPtr("q0"),
+				AnswerValue:    answerValuePtr("maybe"),
+				Questions:      []QuestionSfid{"q1"},
+			},
+			{
+				MasterQuestion: questionPtr("q9"),
+				AnswerValue:    answerValuePtr("yes"),
+				Questions:      []QuestionSfid{"q2"},
+			},
+		},
+	}, map[QuestionSfid][]AnswerValueSfid{
+		"q0": {"yes", "no"},
+	})
+
+	assert.NoError(t, err)
+	assert.Equal(t,
+		[]Problem{
+			{
+				Type:     UnknownAnswerValue,
+				Question: "q1",
+				Rules:    []Rule{{Question: "q1", Type: EnablingAnswerValueRule, Master: "q0", AnswerValue: "maybe"}},
+			},
+			{
+				Type:     UnknownQuestion,
+				Question: "q2",
+				Rules:    []Rule{{Question: "q2", Type: EnablingAnswerValueRule, Master: "q9", AnswerValue: "yes"}},
+			},
+		},
+		problems,
+	)
+}
+
+func TestAnalyze_Redundant(t *testing.T) {
+	problems, err := Analyze(Definitions{
+		Goals: []Goal{
+			{
+				MasterQuestion: questionPtr("q0"),
+				AnswerValue:    answerValuePtr("yes"),
+				Questions:      []QuestionSfid{"q1", "q2"},
+			},
+			{
+				MasterQuestion: questionPtr("q0"),
+				AnswerValue:    answerValuePtr("yes"),
+				Questions:      []QuestionSfid{"q1"},
+			},
+		},
+		EnablingQuestions: map[QuestionSfid][]EnablingQuestion{
+			"q2": {{Question: "q1"}},
+		},
+	}, map[QuestionSfid][]AnswerValueSfid{
+		"q0": {"yes"},
+		"q1": {},
+	})
+
+	assert.NoError(t, err)
+	assert.Equal(t,
+		[]Problem{
+			{
+				Type:     RedundantRule,
+				Question: "q1",
+				Rules:    []Rule{{Question: "q1", Type: EnablingAnswerValueRule, Master: "q0", AnswerValue: "yes"}},
+			},
+			{
+				Type:     RedundantRule,
+				Question: "q2",
+				Rules:    []Rule{{Question: "q2", Type: EnablingAnswerValueRule, Master: "q0", AnswerValue: "yes"}},
+			},
+		},
+		problems,
+	)
+}
+
+func TestAnalyze_NarrowerThanInherited(t *testing.T) {
+	problems, err := Analyze(Definitions{
+		Goals: []Goal{
+			{
+				MasterQuestion: questionPtr("q0"),
+				AnswerValue:    answerValuePtr("a"),
+				Questions:      []QuestionSfid{"q1", "q2"},
+			},
+			{
+				MasterQuestion: questionPtr("q0"),
+				AnswerValue:    answerValuePtr("b"),
+				Questions:      []QuestionSfid{"q1"},
+			},
+		},
+		EnablingQuestions: map[QuestionSfid][]EnablingQuestion{
+			"q2": {{Question: "q1"}},
+		},
+	}, map[QuestionSfid][]AnswerValueSfid{
+		"q0": {"a", "b"},
+		"q1": {},
+	})
+
+	assert.NoError(t, err)
+	assert.Equal(t, []Problem{}, problems)
+}
+
+func TestAnalyze_RedundantEnablingAnswerValue(t *testing.T) {
+	problems, err := Analyze(Definitions{
+		Goals: []Goal{
+			{
+				MasterQuestion: questionPtr("q0"),
+				AnswerValue:    answerValuePtr("yes"),
+				Questions:      []QuestionSfid{"q1", "q2"},
+			},
+			{
+				MasterQuestion: questionPtr("q1"),
+				AnswerValue:    answerValuePtr("yes"),
+				Questions:      []QuestionSfid{"q2"},
+			},
+		},
+	}, map[QuestionSfid][]AnswerValueSfid{
+		"q0": {"yes"},
+		"q1": {"yes"},
+	})
+
+	//q2 is shown for either answer value, but only once q1 is, which requires q0 = yes anyway
+	assert.NoError(t, err)
+	assert.Equal(t,
+		[]Problem{
+			{
+				Type:     RedundantRule,
+				Question: "q2",
+				Rules:    []Rule{{Question: "q2", Type: EnablingAnswerValueRule, Master: "q1", AnswerValue: "yes"}},
+			},
+		},
+		problems,
+	)
+}
+
+func TestAnalyze_UnreachableByContradiction(t *testing.T) {
+	problems, err := Analyze(Definitions{
+		Goals: []Goal{
+			{
+				MasterQuestion: questionPtr("q0"),
+				AnswerValue:    answerValuePtr("yes"),
+				Questions:      []QuestionSfid{"q2"},
+			},
+			{
+				MasterQuestion: questionPtr("q0"),
+				AnswerValue:    answerValuePtr("yes"),
+				Questions:      []QuestionSfid{"q1"},
+				Disables:       true,
+			},
+			{
+				MasterQuestion: questionPtr("q1"),
+				AnswerValue:    answerValuePtr("yes"),
+				Questions:      []QuestionSfid{"q3"},
+				Disables:       true,
+			},
+		},
+	}, map[QuestionSfid][]AnswerValueSfid{
+		"q0": {"yes"},
+		"q1": {"yes"},
+	})
+
+	assert.NoError(t, err)
+	assert.Equal(t, []Problem{}, problems)
+
+	problems, err = Analyze(Definitions{
+		Goals: []Goal{
+			{
+				MasterQuestion: questionPtr("q0"),
+				AnswerValue:    answerValuePtr("yes"),
+				Questions:      []QuestionSfid{"q2"},
+			},
+			{
+				MasterQuestion: questionPtr("q0"),
+				AnswerValue:    answerValuePtr("yes"),
+				Questions:      []QuestionSfid{"q1"},
+				Disables:       true,
+			},
+			{
+				MasterQuestion: questionPtr("q1"),
+				AnswerValue:    answerValuePtr("yes"),
+				Questions:      []QuestionSfid{"q2"},
+				Disables:       true,
+			},
+		},
+	}, map[QuestionSfid][]AnswerValueSfid{
+		"q0": {"yes"},
+		"q1": {"yes"},
+	})
+
+	assert.NoError(t, err)
+	assert.Equal(t,
+		[]Problem{
+			{
+				Type:     UnreachableQuestion,
+				Question: "q2",
+				Rules: []Rule{
+					{Question: "q1", Type: DisablingAnswerValueRule, Master: "q0", AnswerValue: "yes"},
+					{Question: "q2", Type: EnablingAnswerValueRule, Master: "q0", AnswerValue: "yes"},
+				},
+			},
+		},
+		problems,
+	)
+}
+
+func TestAnalyze_UnreachableByInheritedContradiction(t *testing.T) {
+	problems, err := Analyze(Definitions{
+		Goals: []Goal{
+			{
+				MasterQuestion: questionPtr("q0"),
+				AnswerValue:    answerValuePtr("yes"),
+				Questions:      []QuestionSfid{"q1"},
+			},
+			{
+				MasterQuestion: questionPtr("q0"),
+				AnswerValue:    answerValuePtr("yes"),
+				Questions:      []QuestionSfid{"q2"},
+				Disables:       true,
+			},
+		},
+		EnablingQuestions: map[QuestionSfid][]EnablingQuestion{
+			"q2": {{Question: "q1"}},
+		},
+	}, map[QuestionSfid][]AnswerValueSfid{
+		"q0": {"yes"},
+		"q1": {},
+	})
+
+	assert.NoError(t, err)
+	assert.Equal(t,
+		[]Problem{
+			{
+				Type:     UnreachableQuestion,
+				Question: "q2",
+				Rules: []Rule{
+					{Question: "q1", Type: EnablingAnswerValueRule, Master: "q0", AnswerValue: "yes"},
+					{Question: "q2", Type: DisablingAnswerValueRule, Master: "q0", AnswerValue: "yes"},
+				},
+			},
+		},
+		problems,
+	)
+}
+
+func TestAnalyze_UnreachableByAncestorDisabling(t *testing.T) {
+	problems, err := Analyze(Definitions{
+		Goals: []Goal{
+			{
+				MasterQuestion: questionPtr("q1"),
+				AnswerValue:    answerValuePtr("b"),
+				Questions:      []QuestionSfid{"q2"},
+			},
+			{
+				MasterQuestion: questionPtr("q0"),
+				AnswerValue:    answerValuePtr("a"),
+				Questions:      []QuestionSfid{"q2"},
+				Disables:       true,
+			},
+			{
+				MasterQuestion: questionPtr("q0"),
+				AnswerValue:    answerValuePtr("a"),
+				Questions:      []QuestionSfid{"q3"},
+			},
+		},
+		EnablingQuestions: map[QuestionSfid][]EnablingQuestion{
+			"q3": {{Question: "q2"}},
+		},
+	}, map[QuestionSfid][]AnswerValueSfid{
+		"q0": {"a"},
+		"q1": {"b"},
+		"q2": {},
+	})
+
+	assert.NoError(t, err)
+	assert.Equal(t,
+		[]Problem{
+			{
+				Type:     UnreachableQuestion,
+				Question: "q3",
+				Rules: []Rule{
+					{Question: "q2", Type: DisablingAnswerValueRule, Master: "q0", AnswerValue: "a"},
+					{Question: "q3", Type: EnablingAnswerValueRule, Master: "q0", AnswerValue: "a"},
+				},
+			},
+		},
+		problems,
+	)
+}
+
+func TestAnalyze_UnreachableCondition(t *testing.T) {
+	problems, err := Analyze(Definitions{
+		Goals: []Goal{
+			{
+				MasterQuestion: questionPtr("q0"),
+				AnswerValue:    answerValuePtr("yes"),
+				Questions:      []QuestionSfid{"q1"},
+			},
+		},
+		Conditions: map[QuestionSfid]Condition{
+			"q1": Or{
+				Number{Question: "q2", Operator: Less, Value: 0},
+				Not{AnswerSelected{Question: "q0", AnswerValue: "yes"}},
+			},
+			"q2": Number{Question: "q3", Operator: GreaterOrEqual, Value: 0},
+			"q4": And{
+				Number{Question: "q3", Operator: Greater, Value: 10},
+				Not{Number{Question: "q3", Operator: Greater, Value: 5}},
+			},
+		},
+	}, map[QuestionSfid][]AnswerValueSfid{
+		"q0": {"yes"},
+	})
+
+	assert.NoError(t, err)
+	assert.Equal(t,
+		[]Problem{
+			{
+				Type:     UnreachableQuestion,
+				Question: "q4",
+				Rules:    []Rule{{Question: "q4", Type: ConditionRule, Master: "q3"}},
+			},
+		},
+		problems,
+	)
+}
+
+func TestAnalyze_UnreachableThreshold(t *testing.T) {
+	problems, err := Analyze(Definitions{
+		EnablingQuestions: map[QuestionSfid][]EnablingQuestion{
+			"q1": {
+				{Question: "q0", Threshold: Threshold{Field: PercentageField, Operator: Greater, Value: 100}},
+			},
+			"q2": {
+				{Question: "q0", Threshold: Threshold{Field: NumberField, Operator: Greater, Value: 100}},
+			},
+		},
+	}, map[QuestionSfid][]AnswerValueSfid{
+		"q0": {},
+	})
+
+	assert.NoError(t, err)
+	assert.Equal(t,
+		[]Problem{
+			{
+				Type:     UnreachableQuestion,
+				Question: "q1",
+				Rules: []Rule{
+					{
+						Question:  "q1",
+						Type:      EnablingQuestionRule,
+						Master:    "q0",
+						Threshold: Threshold{Field: PercentageField, Operator: Greater, Value: 100},
+					},
+				},
+			},
+		},
+		problems,
+	)
+}
+
+func TestAnalyze_Circular(t *testing.T) {
+	_, err := Analyze(Definitions{
+		EnablingQuestions: map[QuestionSfid][]EnablingQuestion{
+			"q1": {{Question: "q0"}},
+			"q0": {{Question: "q1"}},
+		},
+	}, map[QuestionSfid][]AnswerValueSfid{})
+
+	assert.Equal(t, ErrCircularContingencies, errors.Cause(err))
+}
+
+func TestAnalyze_CircularConditions(t *testing.T) {
+	_, err := Analyze(Definitions{
+		Conditions: map[QuestionSfid]Condition{
+			"q1": AnswerSelected{Question: "q0", AnswerValue: "yes"},
+			"q0": Number{Question: "q1", Operator: Greater, Value: 0},
+		},
+	}, map[QuestionSfid][]AnswerValueSfid{})
+
+	assert.Equal(t, ErrCircularContingencies, errors.Cause(err))
+}
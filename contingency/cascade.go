@@ -0,0 +1,80 @@
+package contingency
+
+//Action taken on the response to a question once a contingency hides it
+type Action string
+
+const (
+	//Archive the response, keeping it for reference but removing it from the assessment
+	Archive Action = "archive"
+	//Clear the response
+	Clear Action = "clear"
+	//RetainExcluded keeps the response, excluded from scoring and reports while hidden
+	RetainExcluded Action = "retain excluded"
+)
+
+//Policy chooses the Action for each question, Default when the question has none
+type Policy struct {
+	Default   Action
+	Questions map[QuestionSfid]Action
+}
+
+//Action for question
+func (p Policy) Action(question QuestionSfid) Action {
+	if action, ok := p.Questions[question]; ok {
+		return action
+	}
+	return p.Default
+}
+
+//Cascade is the Action taken on the response to a newly hidden question
+type Cascade struct {
+	Question QuestionSfid
+	Action   Action
+}
+
+//CascadePlan for the responses affected by a change to a single response.
+//Every hidden response is excluded from scoring and reports, whatever its Action,
+//see sanitize.ApplyCascade and scoring.ApplyCascade.
+type CascadePlan struct {
+	//Hidden responses, sorted by question
+	Hidden []Cascade
+	//Shown questions whose retained responses count again, sorted
+	Shown []QuestionSfid
+}
+
+//Questions the plan takes action on
+func (p CascadePlan) Questions(action Action) []QuestionSfid {
+	result := []QuestionSfid{}
+	for _, cascade := range p.Hidden {
+		if cascade.Action == action {
+			result = append(result, cascade.Question)
+		}
+	}
+	return result
+}
+
+//Cascade sets the response to question, planning what happens to the responses of questions it hides or shows
+func (e *Engine) Cascade(responses Responses, question QuestionSfid, response Response, policy Policy) CascadePlan {
+	flipped := e.Apply(responses, question, response)
+
+	changed := map[QuestionSfid]struct{}{}
+	for q := range flipped {
+		changed[q] = struct{}{}
+	}
+
+	result := CascadePlan{
+		Hidden: []Cascade{},
+		Shown:  []QuestionSfid{},
+	}
+	for _, q := range sortedQuestions(changed) {
+		if _, answered := responses[q]; !answered {
+			continue
+		}
+		if flipped[q] {
+			result.Shown = append(result.Shown, q)
+		} else {
+			result.Hidden = append(result.Hidden, Cascade{Question: q, Action: policy.Action(q)})
+		}
+	}
+	return result
+}
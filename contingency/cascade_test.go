@@ -0,0 +1,65 @@
+package contingency
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCascade(t *testing.T) {
+	engine, err := NewEngine(Definitions{
+		Goals: []Goal{
+			{
+				MasterQuestion: questionPtr("q0"),
+				AnswerValue:    answerValuePtr("yes"),
+				Questions:      []QuestionSfid{"q1", "q2", "q3"},
+			},
+		},
+	})
+	assert.NoError(t, err)
+
+	policy := Policy{
+		Default: RetainExcluded,
+		Questions: map[QuestionSfid]Action{
+			"q2": Clear,
+		},
+	}
+	responses := Responses{
+		"q0": Response{Answers: answers("yes")},
+		"q1": Response{Number: 1},
+		"q2": Response{Number: 2},
+	}
+
+	plan := engine.Cascade(responses, "q0", Response{Answers: answers("no")}, policy)
+	assert.Equal(t,
+		CascadePlan{
+			Hidden: []Cascade{
+				{Question: "q1", Action: RetainExcluded},
+				{Question: "q2", Action: Clear},
+			},
+			Shown: []QuestionSfid{},
+		},
+		plan,
+	)
+	assert.Equal(t, []QuestionSfid{"q2"}, plan.Questions(Clear))
+	assert.Equal(t, []QuestionSfid{}, plan.Questions(Archive))
+
+	plan = engine.Cascade(responses, "q0", Response{Answers: answers("yes")}, policy)
+	assert.Equal(t,
+		CascadePlan{
+			Hidden: []Cascade{},
+			Shown:  []QuestionSfid{"q1", "q2"},
+		},
+		plan,
+	)
+}
+
+func TestPolicyAction(t *testing.T) {
+	policy := Policy{
+		Default:   Archive,
+		Questions: map[QuestionSfid]Action{"q1": Clear},
+	}
+
+	assert.Equal(t, Clear, policy.Action("q1"))
+	assert.Equal(t, Archive, policy.Action("q2"))
+}
@@ -0,0 +1,228 @@
+package contingency
+
+import "sort"
+
+//Condition is a boolean expression over the responses of an assessment
+type Condition interface {
+	Met(responses Responses) bool
+}
+
+//And is met when every condition is met, an empty And is always met
+type And []Condition
+
+//Or is met when any condition is met, an empty Or is never met
+type Or []Condition
+
+//Not is met when Condition isn't
+type Not struct {
+	Condition Condition
+}
+
+//AnswerSelected is met when the response to Question includes AnswerValue
+type AnswerSelected struct {
+	Question    QuestionSfid
+	AnswerValue AnswerValueSfid
+}
+
+//Percentage compares the ValuePercentage of the response to Question against Value
+type Percentage struct {
+	Question QuestionSfid
+	Operator Operator
+	Value    float64
+}
+
+//Number compares the Number of the response to Question against Value
+type Number struct {
+	Question QuestionSfid
+	Operator Operator
+	Value    float64
+}
+
+//Currency compares the Currency of the response to Question against Value
+type Currency struct {
+	Question QuestionSfid
+	Operator Operator
+	Value    float64
+}
+
+//Operator for comparing a response value against a threshold
+type Operator string
+
+const (
+	GreaterOrEqual Operator = ">="
+	Greater        Operator = ">"
+	LessOrEqual    Operator = "<="
+	Less           Operator = "<"
+	Equal          Operator = "="
+	NotEqual       Operator = "!="
+)
+
+//Met if all conditions are met
+func (c And) Met(responses Responses) bool {
+	for _, condition := range c {
+		if !condition.Met(responses) {
+			return false
+		}
+	}
+	return true
+}
+
+//Met if any condition is met
+func (c Or) Met(responses Responses) bool {
+	for _, condition := range c {
+		if condition.Met(responses) {
+			return true
+		}
+	}
+	return false
+}
+
+//Met if c.Condition isn't
+func (c Not) Met(responses Responses) bool {
+	return !c.Condition.Met(responses)
+}
+
+//Met if the answer value was selected
+func (c AnswerSelected) Met(responses Responses) bool {
+	response, ok := responses[c.Question]
+	if !ok {
+		return false
+	}
+	_, selected := response.Answers[c.AnswerValue]
+	return selected
+}
+
+//Met if the question was answered with a percentage satisfying the comparison
+func (c Percentage) Met(responses Responses) bool {
+	response, ok := responses[c.Question]
+	if !ok {
+		return false
+	}
+	return compare(float64(response.ValuePercentage), c.Operator, c.Value)
+}
+
+//Met if the question was answered with a number satisfying the comparison
+func (c Number) Met(responses Responses) bool {
+	response, ok := responses[c.Question]
+	if !ok {
+		return false
+	}
+	return compare(response.Number, c.Operator, c.Value)
+}
+
+//Met if the question was answered with a currency amount satisfying the comparison
+func (c Currency) Met(responses Responses) bool {
+	response, ok := responses[c.Question]
+	if !ok {
+		return false
+	}
+	return compare(response.Currency, c.Operator, c.Value)
+}
+
+func compare(value float64, operator Operator, threshold float64) bool {
+	switch operator {
+	case GreaterOrEqual:
+		return value >= threshold
+	case Greater:
+		return value > threshold
+	case LessOrEqual:
+		return value <= threshold
+	case Less:
+		return value < threshold
+	case Equal:
+		return value == threshold
+	case NotEqual:
+		return value != threshold
+	default:
+		return false
+	}
+}
+
+//FromDependencies converts dependency maps into the equivalent Condition: any enabling answer value,
+//and any enabling question meeting one of its thresholds, and none of the disabling answer values
+func FromDependencies(
+	disablingAnswerValues AnswerDependencies,
+	enablingAnswerValues AnswerDependencies,
+	enablingQuestions QuestionDependencies) Condition {
+
+	result := And{}
+
+	if len(enablingAnswerValues) > 0 {
+		enabling := Or{}
+		for _, q := range sortedAnswerDependencyQuestions(enablingAnswerValues) {
+			for _, answerValue := range sortedAnswerValues(enablingAnswerValues[q]) {
+				enabling = append(enabling, AnswerSelected{Question: q, AnswerValue: answerValue})
+			}
+		}
+		result = append(result, enabling)
+	}
+
+	if len(enablingQuestions) > 0 {
+		enabling := Or{}
+		for _, q := range sortedQuestionDependencyQuestions(enablingQuestions) {
+			for _, threshold := range sortedThresholds(enablingQuestions[q]) {
+				enabling = append(enabling, threshold.condition(q))
+			}
+		}
+		result = append(result, enabling)
+	}
+
+	for _, q := range sortedAnswerDependencyQuestions(disablingAnswerValues) {
+		for _, answerValue := range sortedAnswerValues(disablingAnswerValues[q]) {
+			result = append(result, Not{AnswerSelected{Question: q, AnswerValue: answerValue}})
+		}
+	}
+
+	return result
+}
+
+//addConditionQuestions adds every question referenced by condition to result
+func addConditionQuestions(condition Condition, result map[QuestionSfid]struct{}) {
+	switch condition := condition.(type) {
+	case And:
+		for _, c := range condition {
+			addConditionQuestions(c, result)
+		}
+	case Or:
+		for _, c := range condition {
+			addConditionQuestions(c, result)
+		}
+	case Not:
+		addConditionQuestions(condition.Condition, result)
+	case AnswerSelected:
+		result[condition.Question] = struct{}{}
+	case Percentage:
+		result[condition.Question] = struct{}{}
+	case Number:
+		result[condition.Question] = struct{}{}
+	case Currency:
+		result[condition.Question] = struct{}{}
+	}
+}
+
+func sortedQuestions(questions map[QuestionSfid]struct{}) []QuestionSfid {
+	result := make([]QuestionSfid, 0, len(questions))
+	for q := range questions {
+		result = append(result, q)
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i] < result[j] })
+	return result
+}
+
+func sortedAnswerDependencyQuestions(deps AnswerDependencies) []QuestionSfid {
+	result := make([]QuestionSfid, 0, len(deps))
+	for q := range deps {
+		result = append(result, q)
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i] < result[j] })
+	return result
+}
+
+func sortedAnswerValues(answerValues AnswerValues) []AnswerValueSfid {
+	result := make([]AnswerValueSfid, 0, len(answerValues))
+	for a := range answerValues {
+		result = append(result, a)
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i] < result[j] })
+	return result
+}
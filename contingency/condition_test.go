@@ -0,0 +1,139 @@
+package contingency
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAnswerSelected(t *testing.T) {
+	condition := AnswerSelected{Question: "q1", AnswerValue: "a"}
+
+	assert.False(t, condition.Met(Responses{}))
+	assert.False(t, condition.Met(Responses{"q1": Response{Answers: answers("b")}}))
+	assert.True(t, condition.Met(Responses{"q1": Response{Answers: answers("a", "b")}}))
+}
+
+func TestPercentage(t *testing.T) {
+	condition := Percentage{Question: "q1", Operator: GreaterOrEqual, Value: 50}
+
+	assert.False(t, condition.Met(Responses{}))
+	assert.False(t, condition.Met(Responses{"q1": Response{ValuePercentage: 49}}))
+	assert.True(t, condition.Met(Responses{"q1": Response{ValuePercentage: 50}}))
+}
+
+func TestNumber(t *testing.T) {
+	condition := Number{Question: "q1", Operator: Less, Value: 10}
+
+	assert.False(t, condition.Met(Responses{}))
+	assert.True(t, condition.Met(Responses{"q1": Response{Number: 9.5}}))
+	assert.False(t, condition.Met(Responses{"q1": Response{Number: 10}}))
+}
+
+func TestCurrency(t *testing.T) {
+	condition := Currency{Question: "q1", Operator: Greater, Value: 0}
+
+	assert.False(t, condition.Met(Responses{}))
+	assert.False(t, condition.Met(Responses{"q1": Response{Currency: 0}}))
+	assert.True(t, condition.Met(Responses{"q1": Response{Currency: 0.01}}))
+}
+
+func TestCompare(t *testing.T) {
+	assert.True(t, compare(1, GreaterOrEqual, 1))
+	assert.False(t, compare(1, Greater, 1))
+	assert.True(t, compare(1, LessOrEqual, 1))
+	assert.False(t, compare(1, Less, 1))
+	assert.True(t, compare(1, Equal, 1))
+	assert.False(t, compare(1, NotEqual, 1))
+	assert.False(t, compare(1, "~", 1))
+}
+
+func TestAndOrNot(t *testing.T) {
+	// (q1=a or q1=b) and not q7=yes and q9 >= 50%
+	condition := And{
+		Or{
+			AnswerSelected{Question: "q1", AnswerValue: "a"},
+			AnswerSelected{Question: "q1", AnswerValue: "b"},
+		},
+		Not{AnswerSelected{Question: "q7", AnswerValue: "yes"}},
+		Percentage{Question: "q9", Operator: GreaterOrEqual, Value: 50},
+	}
+
+	assert.True(t, condition.Met(Responses{
+		"q1": Response{Answers: answers("b")},
+		"q9": Response{ValuePercentage: 50},
+	}))
+	assert.False(t, condition.Met(Responses{
+		"q1": Response{Answers: answers("c")},
+		"q9": Response{ValuePercentage: 50},
+	}))
+	assert.False(t, condition.Met(Responses{
+		"q1": Response{Answers: answers("a")},
+		"q7": Response{Answers: answers("yes")},
+		"q9": Response{ValuePercentage: 50},
+	}))
+	assert.False(t, condition.Met(Responses{
+		"q1": Response{Answers: answers("a")},
+		"q9": Response{ValuePercentage: 49},
+	}))
+}
+
+func TestAndOrEmpty(t *testing.T) {
+	assert.True(t, And{}.Met(Responses{}))
+	assert.False(t, Or{}.Met(Responses{}))
+}
+
+func TestFromDependencies(t *testing.T) {
+	condition := FromDependencies(
+		AnswerDependencies{"q3": AnswerValues{"a3": struct{}{}}},
+		AnswerDependencies{"q2": AnswerValues{"a2": struct{}{}}, "q1": AnswerValues{"a1": struct{}{}}},
+		QuestionDependencies{"q4": Thresholds{DefaultThreshold: struct{}{}}},
+	)
+
+	assert.Equal(t,
+		And{
+			Or{
+				AnswerSelected{Question: "q1", AnswerValue: "a1"},
+				AnswerSelected{Question: "q2", AnswerValue: "a2"},
+			},
+			Or{
+				Percentage{Question: "q4", Operator: GreaterOrEqual, Value: 100},
+			},
+			Not{AnswerSelected{Question: "q3", AnswerValue: "a3"}},
+		},
+		condition,
+	)
+}
+
+func TestFromDependencies_Empty(t *testing.T) {
+	condition := FromDependencies(AnswerDependencies{}, AnswerDependencies{}, QuestionDependencies{})
+
+	assert.Equal(t, And{}, condition)
+}
+
+func TestFromDependencies_Thresholds(t *testing.T) {
+	condition := FromDependencies(
+		AnswerDependencies{},
+		AnswerDependencies{},
+		QuestionDependencies{
+			"q1": Thresholds{
+				Threshold{Field: PercentageField, Operator: Greater, Value: 0}: struct{}{},
+				Threshold{Field: NumberField, Operator: Less, Value: 50}:       struct{}{},
+			},
+			"q2": Thresholds{
+				Threshold{Field: CurrencyField, Operator: GreaterOrEqual, Value: 1000}: struct{}{},
+			},
+		},
+	)
+
+	assert.Equal(t,
+		And{
+			Or{
+				Number{Question: "q1", Operator: Less, Value: 50},
+				Percentage{Question: "q1", Operator: Greater, Value: 0},
+				Currency{Question: "q2", Operator: GreaterOrEqual, Value: 1000},
+			},
+		},
+		condition,
+	)
+}
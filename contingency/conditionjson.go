@@ -0,0 +1,170 @@
+package contingency
+
+import (
+	"encoding/json"
+
+	"github.com/pkg/errors"
+)
+
+//ErrUnknownCondition when decoding a condition whose type isn't one of the Condition types
+var ErrUnknownCondition = errors.New("unknown condition type")
+
+//Condition types of the JSON encoding, e.g. {"type": "not", "condition": {"type": "answerSelected", ...}}
+const (
+	andConditionType            = "and"
+	orConditionType             = "or"
+	notConditionType            = "not"
+	answerSelectedConditionType = "answerSelected"
+	percentageConditionType     = "percentage"
+	numberConditionType         = "number"
+	currencyConditionType       = "currency"
+)
+
+//conditionJSON holds the fields of every condition type, Type determining which apply
+type conditionJSON struct {
+	Type        string            `json:"type"`
+	Conditions  []json.RawMessage `json:"conditions"`
+	Condition   json.RawMessage   `json:"condition"`
+	Question    QuestionSfid      `json:"question"`
+	AnswerValue AnswerValueSfid   `json:"answerValue"`
+	Operator    Operator          `json:"operator"`
+	Value       float64           `json:"value"`
+}
+
+type conditionsJSON struct {
+	Type       string      `json:"type"`
+	Conditions []Condition `json:"conditions"`
+}
+
+type notJSON struct {
+	Type      string    `json:"type"`
+	Condition Condition `json:"condition"`
+}
+
+type answerSelectedJSON struct {
+	Type        string          `json:"type"`
+	Question    QuestionSfid    `json:"question"`
+	AnswerValue AnswerValueSfid `json:"answerValue"`
+}
+
+type comparisonJSON struct {
+	Type     string       `json:"type"`
+	Question QuestionSfid `json:"question"`
+	Operator Operator     `json:"operator"`
+	Value    float64      `json:"value"`
+}
+
+//UnmarshalCondition decodes a condition from its JSON encoding
+func UnmarshalCondition(data []byte) (Condition, error) {
+	var raw conditionJSON
+	err := json.Unmarshal(data, &raw)
+	if err != nil {
+		return nil, err
+	}
+
+	switch raw.Type {
+	case andConditionType:
+		conditions, err := unmarshalConditions(raw.Conditions)
+		return And(conditions), err
+	case orConditionType:
+		conditions, err := unmarshalConditions(raw.Conditions)
+		return Or(conditions), err
+	case notConditionType:
+		condition, err := UnmarshalCondition(raw.Condition)
+		if err != nil {
+			return nil, err
+		}
+		return Not{Condition: condition}, nil
+	case answerSelectedConditionType:
+		return AnswerSelected{Question: raw.Question, AnswerValue: raw.AnswerValue}, nil
+	case percentageConditionType:
+		return Percentage{Question: raw.Question, Operator: raw.Operator, Value: raw.Value}, nil
+	case numberConditionType:
+		return Number{Question: raw.Question, Operator: raw.Operator, Value: raw.Value}, nil
+	case currencyConditionType:
+		return Currency{Question: raw.Question, Operator: raw.Operator, Value: raw.Value}, nil
+	default:
+		return nil, errors.Wrapf(ErrUnknownCondition, "%q", raw.Type)
+	}
+}
+
+func unmarshalConditions(data []json.RawMessage) ([]Condition, error) {
+	result := make([]Condition, 0, len(data))
+	for _, d := range data {
+		condition, err := UnmarshalCondition(d)
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, condition)
+	}
+	return result, nil
+}
+
+//UnmarshalJSON decodes Conditions from their JSON encoding
+func (d *Definitions) UnmarshalJSON(data []byte) error {
+	type definitions Definitions
+	var raw struct {
+		definitions
+		Conditions map[QuestionSfid]json.RawMessage
+	}
+	err := json.Unmarshal(data, &raw)
+	if err != nil {
+		return err
+	}
+
+	*d = Definitions(raw.definitions)
+	d.Conditions = nil
+	if raw.Conditions != nil {
+		d.Conditions = make(map[QuestionSfid]Condition, len(raw.Conditions))
+	}
+	for q, data := range raw.Conditions {
+		condition, err := UnmarshalCondition(data)
+		if err != nil {
+			return errors.Wrapf(err, "condition of %v", q)
+		}
+		d.Conditions[q] = condition
+	}
+	return nil
+}
+
+//MarshalJSON as {"type": "and", "conditions": [...]}
+func (c And) MarshalJSON() ([]byte, error) {
+	return json.Marshal(conditionsJSON{Type: andConditionType, Conditions: nonNilConditions(c)})
+}
+
+//MarshalJSON as {"type": "or", "conditions": [...]}
+func (c Or) MarshalJSON() ([]byte, error) {
+	return json.Marshal(conditionsJSON{Type: orConditionType, Conditions: nonNilConditions(c)})
+}
+
+//MarshalJSON as {"type": "not", "condition": {...}}
+func (c Not) MarshalJSON() ([]byte, error) {
+	return json.Marshal(notJSON{Type: notConditionType, Condition: c.Condition})
+}
+
+//MarshalJSON as {"type": "answerSelected", "question": ..., "answerValue": ...}
+func (c AnswerSelected) MarshalJSON() ([]byte, error) {
+	return json.Marshal(answerSelectedJSON{Type: answerSelectedConditionType, Question: c.Question, AnswerValue: c.AnswerValue})
+}
+
+//MarshalJSON as {"type": "percentage", "question": ..., "operator": ..., "value": ...}
+func (c Percentage) MarshalJSON() ([]byte, error) {
+	return json.Marshal(comparisonJSON{Type: percentageConditionType, Question: c.Question, Operator: c.Operator, Value: c.Value})
+}
+
+//MarshalJSON as {"type": "number", "question": ..., "operator": ..., "value": ...}
+func (c Number) MarshalJSON() ([]byte, error) {
+	return json.Marshal(comparisonJSON{Type: numberConditionType, Question: c.Question, Operator: c.Operator, Value: c.Value})
+}
+
+//MarshalJSON as {"type": "currency", "question": ..., "operator": ..., "value": ...}
+func (c Currency) MarshalJSON() ([]byte, error) {
+	return json.Marshal(comparisonJSON{Type: currencyConditionType, Question: c.Question, Operator: c.Operator, Value: c.Value})
+}
+
+func nonNilConditions(conditions []Condition) []Condition {
+	if conditions == nil {
+		return []Condition{}
+	}
+	return conditions
+}
@@ -0,0 +1,64 @@
+package contingency
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"testing"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+)
+
+func fixtureDefinitions() Definitions {
+	return Definitions{
+		Goals: []Goal{
+			{
+				MasterQuestion: questionPtr("q0"),
+				AnswerValue:    answerValuePtr("yes"),
+				Questions:      []QuestionSfid{"q1"},
+			},
+		},
+		EnablingQuestions: map[QuestionSfid][]EnablingQuestion{
+			"q2": {{Question: "q1", Threshold: Threshold{Field: PercentageField, Operator: GreaterOrEqual, Value: 50}}},
+		},
+		Conditions: map[QuestionSfid]Condition{
+			"q1": Number{Question: "q8", Operator: Greater, Value: 0},
+			"q3": And{
+				Or{
+					AnswerSelected{Question: "q0", AnswerValue: "yes"},
+					Percentage{Question: "q1", Operator: Less, Value: 25},
+				},
+				Not{Currency{Question: "q9", Operator: GreaterOrEqual, Value: 1000}},
+			},
+		},
+	}
+}
+
+func TestDefinitions_UnmarshalJSON(t *testing.T) {
+	data, err := ioutil.ReadFile("testdata/definitions.json")
+	assert.NoError(t, err)
+
+	var defs Definitions
+	err = json.Unmarshal(data, &defs)
+	assert.NoError(t, err)
+	assert.Equal(t, fixtureDefinitions(), defs)
+}
+
+func TestDefinitions_MarshalJSON(t *testing.T) {
+	data, err := json.Marshal(fixtureDefinitions())
+	assert.NoError(t, err)
+
+	var defs Definitions
+	err = json.Unmarshal(data, &defs)
+	assert.NoError(t, err)
+	assert.Equal(t, fixtureDefinitions(), defs)
+}
+
+func TestUnmarshalCondition_Unknown(t *testing.T) {
+	_, err := UnmarshalCondition([]byte(`{"type": "xor", "conditions": []}`))
+	assert.Equal(t, ErrUnknownCondition, errors.Cause(err))
+
+	var defs Definitions
+	err = json.Unmarshal([]byte(`{"Conditions": {"q1": {"type": "not", "condition": {"type": "maybe"}}}}`), &defs)
+	assert.Equal(t, ErrUnknownCondition, errors.Cause(err))
+}
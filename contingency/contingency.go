@@ -1,6 +1,11 @@
 package contingency
 
-import "github.com/pkg/errors"
+import (
+	"fmt"
+	"strings"
+
+	"github.com/pkg/errors"
+)
 
 //QuestionSfid Salesforce Id
 type QuestionSfid string
@@ -11,19 +16,24 @@ type AnswerValueSfid string
 //Response supplied for a specific question
 type Response struct {
 	ValuePercentage int
+	Number          float64
+	Currency        float64
 	Answers         map[AnswerValueSfid]struct{}
 }
 
 //Responses is the set of all responses for the assessment
 type Responses map[QuestionSfid]Response
 
-//AnswerDependencies question to answer value
-type AnswerDependencies map[QuestionSfid]AnswerValueSfid
+//AnswerValues set of answer values
+type AnswerValues map[AnswerValueSfid]struct{}
+
+//AnswerDependencies question to the answer values triggering the dependency
+type AnswerDependencies map[QuestionSfid]AnswerValues
 
 type questionDependencies struct {
 	DisablingAnswerValues AnswerDependencies
 	EnablingAnswerValues  AnswerDependencies
-	EnablingQuestions     map[QuestionSfid]struct{}
+	EnablingQuestions     QuestionDependencies
 }
 
 type goalQuestionContingencies struct {
@@ -41,81 +51,35 @@ const (
 //ErrCircularContingencies when there is circular contingencies
 var ErrCircularContingencies = errors.New("circular contingencies")
 
-func addDescendantContingencies(
-	q QuestionSfid,
-	result *questionDependencies,
-	deps map[QuestionSfid]questionDependencies,
-) error {
-	questionDeps, ok := deps[q]
-	if !ok {
-		return nil
-	}
-	for disablingQuestion, disablingAnswerValue := range questionDeps.DisablingAnswerValues {
-		_, alreadyHasDep := result.DisablingAnswerValues[disablingQuestion]
-
-		if alreadyHasDep {
-			return errors.Wrapf(ErrCircularContingencies, string(q))
-		}
-
-		result.DisablingAnswerValues[disablingQuestion] = disablingAnswerValue
-
-		err := addDescendantContingencies(disablingQuestion, result, deps)
-		if err != nil {
-			return err
-		}
-	}
-
-	for enablingQuestion, enablingAnswerValue := range questionDeps.EnablingAnswerValues {
-		_, alreadyHasDep := result.EnablingAnswerValues[enablingQuestion]
-
-		if alreadyHasDep {
-			return errors.Wrapf(ErrCircularContingencies, string(q))
-		}
-
-		result.EnablingAnswerValues[enablingQuestion] = enablingAnswerValue
-
-		err := addDescendantContingencies(enablingQuestion, result, deps)
-		if err != nil {
-			return err
-		}
-	}
-
-	for enablingQuestion := range questionDeps.EnablingQuestions {
-		_, alreadyHasDep := result.EnablingQuestions[enablingQuestion]
-		if alreadyHasDep {
-			return errors.Wrapf(ErrCircularContingencies, string(q))
-		}
-
-		result.EnablingQuestions[enablingQuestion] = struct{}{}
+//CycleError reports the questions of a circular contingency, each depending on the next,
+//the first and last being the same question.  Its Cause is ErrCircularContingencies.
+type CycleError struct {
+	Path []QuestionSfid
+}
 
-		err := addDescendantContingencies(enablingQuestion, result, deps)
-		if err != nil {
-			return err
-		}
+func (e *CycleError) Error() string {
+	path := make([]string, len(e.Path))
+	for i, q := range e.Path {
+		path[i] = string(q)
 	}
-
-	return nil
+	return fmt.Sprintf("%s: %s", ErrCircularContingencies, strings.Join(path, " → "))
 }
 
-func expand(
-	contingencies map[QuestionSfid]questionDependencies,
-) (map[QuestionSfid]questionDependencies, error) {
+//Cause for github.com/pkg/errors.Cause
+func (e *CycleError) Cause() error {
+	return ErrCircularContingencies
+}
 
-	result := map[QuestionSfid]questionDependencies{}
-	for q := range contingencies {
-		questionDeps := questionDependencies{
-			DisablingAnswerValues: AnswerDependencies{},
-			EnablingAnswerValues:  AnswerDependencies{},
-			EnablingQuestions:     map[QuestionSfid]struct{}{},
-		}
-		err := addDescendantContingencies(q, &questionDeps, contingencies)
-		if err != nil {
-			return map[QuestionSfid]questionDependencies{}, err
-		}
-		result[q] = questionDeps
+//addAnswerValues merges answerValues into the answer values result has for q
+func addAnswerValues(result AnswerDependencies, q QuestionSfid, answerValues AnswerValues) {
+	existing, ok := result[q]
+	if !ok {
+		existing = AnswerValues{}
+		result[q] = existing
+	}
+	for answerValue := range answerValues {
+		existing[answerValue] = struct{}{}
 	}
-
-	return result, nil
 }
 
 func fromGoal(
@@ -131,14 +95,18 @@ func fromGoal(
 		questionDeps := questionDependencies{
 			DisablingAnswerValues: AnswerDependencies{},
 			EnablingAnswerValues:  AnswerDependencies{},
-			EnablingQuestions:     map[QuestionSfid]struct{}{},
+			EnablingQuestions:     QuestionDependencies{},
 		}
-		if disabledByAnswerValue == answerValueDisables && masterQuestion != nil {
-			questionDeps.DisablingAnswerValues[*masterQuestion] = *answerValue
+		if masterQuestion == nil || answerValue == nil {
+			result[q] = questionDeps
+			continue
+		}
+		if disabledByAnswerValue == answerValueDisables {
+			questionDeps.DisablingAnswerValues[*masterQuestion] = AnswerValues{*answerValue: struct{}{}}
 		}
 
-		if disabledByAnswerValue == answerValueEnables && masterQuestion != nil {
-			questionDeps.EnablingAnswerValues[*masterQuestion] = *answerValue
+		if disabledByAnswerValue == answerValueEnables {
+			questionDeps.EnablingAnswerValues[*masterQuestion] = AnswerValues{*answerValue: struct{}{}}
 		}
 		result[q] = questionDeps
 	}
@@ -151,63 +119,7 @@ func Enable(
 	responses Responses,
 	disablingAnswerValues AnswerDependencies,
 	enablingAnswerValues AnswerDependencies,
-	enablingQuestions map[QuestionSfid]struct{}) bool {
-
-	return enabledByAnswerValue(responses, enablingAnswerValues) &&
-		enabledByQuestionValuePercentage(responses, enablingQuestions) &&
-		!disabledByAnswerValues(responses, disablingAnswerValues)
-}
-
-//disabledByAnswerValues if a question is hidden by answer values
-func disabledByAnswerValues(
-	responses map[QuestionSfid]Response,
-	disablingAnswerValues map[QuestionSfid]AnswerValueSfid) bool {
-
-	for disablingQuestion, disablingAnswer := range disablingAnswerValues {
-		if response, hasDisablingResponse := responses[disablingQuestion]; hasDisablingResponse {
-			_, hasDisablingAnswer := response.Answers[disablingAnswer]
-			if hasDisablingAnswer {
-				return true
-			}
-		}
-	}
-	return false
-}
-
-func enabledByAnswerValue(
-	responses map[QuestionSfid]Response,
-	enablingAnswerValues map[QuestionSfid]AnswerValueSfid) bool {
-
-	if len(enablingAnswerValues) == 0 {
-		return true
-	}
-
-	for enablingQuestion, enablingAnswer := range enablingAnswerValues {
-		if response, hasEnablingResponse := responses[enablingQuestion]; hasEnablingResponse {
-			_, hasEnablingAnswer := response.Answers[enablingAnswer]
-			if hasEnablingAnswer {
-				return true
-			}
-		}
-	}
-	return false
-}
-
-func enabledByQuestionValuePercentage(
-	responses map[QuestionSfid]Response,
-	enablingQuestions map[QuestionSfid]struct{}) bool {
-
-	if len(enablingQuestions) == 0 {
-		return true
-	}
-
-	for enablingQuestion := range enablingQuestions {
-		if response, hasEnablingResponse := responses[enablingQuestion]; hasEnablingResponse {
-			if response.ValuePercentage >= 100 {
-				return true
-			}
-		}
-	}
+	enablingQuestions QuestionDependencies) bool {
 
-	return false
+	return FromDependencies(disablingAnswerValues, enablingAnswerValues, enablingQuestions).Met(responses)
 }
@@ -11,7 +11,7 @@ func TestNoContingency(t *testing.T) {
 		Responses{},
 		AnswerDependencies{},
 		AnswerDependencies{},
-		map[QuestionSfid]struct{}{},
+		QuestionDependencies{},
 	)
 
 	assert.True(t, enabled)
@@ -27,10 +27,10 @@ func TestDisablingAnswerValueNotMatched(t *testing.T) {
 			},
 		},
 		AnswerDependencies{
-			"masterQ": "masterV",
+			"masterQ": AnswerValues{"masterV": struct{}{}},
 		},
 		AnswerDependencies{},
-		map[QuestionSfid]struct{}{},
+		QuestionDependencies{},
 	)
 	assert.True(t, enabled)
 }
@@ -45,10 +45,10 @@ func TestDisablingAnswerValueMatched(t *testing.T) {
 			},
 		},
 		AnswerDependencies{
-			"masterQ": "masterV",
+			"masterQ": AnswerValues{"masterV": struct{}{}},
 		},
 		AnswerDependencies{},
-		map[QuestionSfid]struct{}{},
+		QuestionDependencies{},
 	)
 	assert.False(t, enabled)
 }
@@ -58,9 +58,9 @@ func TestEnablingAnswerValueNotMatched(t *testing.T) {
 		Responses{},
 		AnswerDependencies{},
 		AnswerDependencies{
-			"masterQ": "masterV",
+			"masterQ": AnswerValues{"masterV": struct{}{}},
 		},
-		map[QuestionSfid]struct{}{},
+		QuestionDependencies{},
 	)
 	assert.False(t, enabled)
 }
@@ -76,9 +76,9 @@ func TestEnablingAnswerValueMatched(t *testing.T) {
 		},
 		AnswerDependencies{},
 		AnswerDependencies{
-			"masterQ": "masterV",
+			"masterQ": AnswerValues{"masterV": struct{}{}},
 		},
-		map[QuestionSfid]struct{}{},
+		QuestionDependencies{},
 	)
 	assert.True(t, enabled)
 }
@@ -88,9 +88,9 @@ func TestEnablingQuestionUnmet(t *testing.T) {
 		Responses{},
 		AnswerDependencies{},
 		AnswerDependencies{},
-		map[QuestionSfid]struct{}{
-			"masterQ":  struct{}{},
-			"masterQ2": struct{}{},
+		QuestionDependencies{
+			"masterQ":  Thresholds{DefaultThreshold: struct{}{}},
+			"masterQ2": Thresholds{DefaultThreshold: struct{}{}},
 		},
 	)
 	assert.False(t, enabled)
@@ -105,9 +105,9 @@ func TestEnablingQuestionUnmetPercentage(t *testing.T) {
 		},
 		AnswerDependencies{},
 		AnswerDependencies{},
-		map[QuestionSfid]struct{}{
-			"masterQ":  struct{}{},
-			"masterQ2": struct{}{},
+		QuestionDependencies{
+			"masterQ":  Thresholds{DefaultThreshold: struct{}{}},
+			"masterQ2": Thresholds{DefaultThreshold: struct{}{}},
 		},
 	)
 	assert.False(t, enabled)
@@ -122,9 +122,9 @@ func TestEnablingQuestionMetPercentage(t *testing.T) {
 		},
 		AnswerDependencies{},
 		AnswerDependencies{},
-		map[QuestionSfid]struct{}{
-			"masterQ":  struct{}{},
-			"masterQ2": struct{}{},
+		QuestionDependencies{
+			"masterQ":  Thresholds{DefaultThreshold: struct{}{}},
+			"masterQ2": Thresholds{DefaultThreshold: struct{}{}},
 		},
 	)
 	assert.True(t, enabled)
@@ -140,12 +140,12 @@ func TestDisableOverridesEnable(t *testing.T) {
 			},
 		},
 		AnswerDependencies{
-			"masterQ": "masterV",
+			"masterQ": AnswerValues{"masterV": struct{}{}},
 		},
 		AnswerDependencies{
-			"masterQ": "masterV",
+			"masterQ": AnswerValues{"masterV": struct{}{}},
 		},
-		map[QuestionSfid]struct{}{},
+		QuestionDependencies{},
 	)
 	assert.False(t, enabled)
 }
@@ -161,12 +161,12 @@ func TestDisableOverridesEnableByValuePercentage(t *testing.T) {
 			},
 		},
 		AnswerDependencies{
-			"masterQ": "masterV",
+			"masterQ": AnswerValues{"masterV": struct{}{}},
 		},
 		AnswerDependencies{},
-		map[QuestionSfid]struct{}{
-			"masterQ":  struct{}{},
-			"masterQ2": struct{}{},
+		QuestionDependencies{
+			"masterQ":  Thresholds{DefaultThreshold: struct{}{}},
+			"masterQ2": Thresholds{DefaultThreshold: struct{}{}},
 		},
 	)
 	assert.False(t, enabled)
@@ -189,17 +189,17 @@ func TestFromGoalDisableByAnswerValue(t *testing.T) {
 		map[QuestionSfid]questionDependencies{
 			"goalq1": questionDependencies{
 				DisablingAnswerValues: AnswerDependencies{
-					"q1": "a1",
+					"q1": AnswerValues{"a1": struct{}{}},
 				},
 				EnablingAnswerValues: AnswerDependencies{},
-				EnablingQuestions:    map[QuestionSfid]struct{}{},
+				EnablingQuestions:    QuestionDependencies{},
 			},
 			"goalq2": questionDependencies{
 				DisablingAnswerValues: AnswerDependencies{
-					"q1": "a1",
+					"q1": AnswerValues{"a1": struct{}{}},
 				},
 				EnablingAnswerValues: AnswerDependencies{},
-				EnablingQuestions:    map[QuestionSfid]struct{}{},
+				EnablingQuestions:    QuestionDependencies{},
 			},
 		},
 		result,
@@ -224,16 +224,16 @@ func TestFromGoalEnabledByAnswerValue(t *testing.T) {
 			"goalq1": questionDependencies{
 				DisablingAnswerValues: AnswerDependencies{},
 				EnablingAnswerValues: AnswerDependencies{
-					"q1": "a1",
+					"q1": AnswerValues{"a1": struct{}{}},
 				},
-				EnablingQuestions: map[QuestionSfid]struct{}{},
+				EnablingQuestions: QuestionDependencies{},
 			},
 			"goalq2": questionDependencies{
 				DisablingAnswerValues: AnswerDependencies{},
 				EnablingAnswerValues: AnswerDependencies{
-					"q1": "a1",
+					"q1": AnswerValues{"a1": struct{}{}},
 				},
-				EnablingQuestions: map[QuestionSfid]struct{}{},
+				EnablingQuestions: QuestionDependencies{},
 			},
 		},
 		result,
@@ -256,12 +256,12 @@ func TestFromGoalWithNoMasterQ(t *testing.T) {
 			"goalq1": questionDependencies{
 				DisablingAnswerValues: AnswerDependencies{},
 				EnablingAnswerValues:  AnswerDependencies{},
-				EnablingQuestions:     map[QuestionSfid]struct{}{},
+				EnablingQuestions:     QuestionDependencies{},
 			},
 			"goalq2": questionDependencies{
 				DisablingAnswerValues: AnswerDependencies{},
 				EnablingAnswerValues:  AnswerDependencies{},
-				EnablingQuestions:     map[QuestionSfid]struct{}{},
+				EnablingQuestions:     QuestionDependencies{},
 			},
 		},
 		result,
@@ -284,318 +284,57 @@ func TestFromGoalWithNoMasterQ_Disabled(t *testing.T) {
 			"goalq1": questionDependencies{
 				DisablingAnswerValues: AnswerDependencies{},
 				EnablingAnswerValues:  AnswerDependencies{},
-				EnablingQuestions:     map[QuestionSfid]struct{}{},
+				EnablingQuestions:     QuestionDependencies{},
 			},
 			"goalq2": questionDependencies{
 				DisablingAnswerValues: AnswerDependencies{},
 				EnablingAnswerValues:  AnswerDependencies{},
-				EnablingQuestions:     map[QuestionSfid]struct{}{},
+				EnablingQuestions:     QuestionDependencies{},
 			},
 		},
 		result,
 	)
 }
 
-func TestExpand_empty(t *testing.T) {
-	result, err := expand(map[QuestionSfid]questionDependencies{})
-
-	assert.NoError(t, err)
-	assert.Equal(t,
-		map[QuestionSfid]questionDependencies{},
-		result,
-	)
-}
-
-func TestExpand_noCascade(t *testing.T) {
-	result, err := expand(map[QuestionSfid]questionDependencies{
-		"q1": questionDependencies{
-			DisablingAnswerValues: AnswerDependencies{
-				"q0": "a1",
-			},
-		},
-		"q2": questionDependencies{
-			DisablingAnswerValues: AnswerDependencies{
-				"q0": "a1",
-			},
-		},
-	})
-
-	assert.NoError(t, err)
-	assert.Equal(t,
-		map[QuestionSfid]questionDependencies{
-			"q1": questionDependencies{
-				DisablingAnswerValues: AnswerDependencies{
-					"q0": "a1",
-				},
-				EnablingAnswerValues: AnswerDependencies{},
-				EnablingQuestions:    map[QuestionSfid]struct{}{},
-			},
-			"q2": questionDependencies{
-				DisablingAnswerValues: AnswerDependencies{
-					"q0": "a1",
-				},
-				EnablingAnswerValues: AnswerDependencies{},
-				EnablingQuestions:    map[QuestionSfid]struct{}{},
-			},
-		},
-		result,
-	)
-}
-
-func TestExpand_disablingTwoLevels(t *testing.T) {
-	result, err := expand(map[QuestionSfid]questionDependencies{
-		"q1": questionDependencies{
-			DisablingAnswerValues: AnswerDependencies{
-				"q0": "aq0",
-			},
-		},
-		"q2": questionDependencies{
-			DisablingAnswerValues: AnswerDependencies{
-				"q1": "aq1",
-			},
-		},
-		"q3": questionDependencies{
-			DisablingAnswerValues: AnswerDependencies{
-				"q2": "aq2",
-			},
-		},
-	})
-
-	assert.NoError(t, err)
-	assert.Equal(t,
-		map[QuestionSfid]questionDependencies{
-			"q1": questionDependencies{
-				DisablingAnswerValues: AnswerDependencies{
-					"q0": "aq0",
-				},
-				EnablingAnswerValues: AnswerDependencies{},
-				EnablingQuestions:    map[QuestionSfid]struct{}{},
-			},
-			"q2": questionDependencies{
-				DisablingAnswerValues: AnswerDependencies{
-					"q0": "aq0",
-					"q1": "aq1",
-				},
-				EnablingAnswerValues: AnswerDependencies{},
-				EnablingQuestions:    map[QuestionSfid]struct{}{},
-			},
-			"q3": questionDependencies{
-				DisablingAnswerValues: AnswerDependencies{
-					"q0": "aq0",
-					"q1": "aq1",
-					"q2": "aq2",
+func TestEnablingAnswerValuesAnyMatched(t *testing.T) {
+	enabled := Enable(
+		Responses{
+			"masterQ": Response{
+				Answers: map[AnswerValueSfid]struct{}{
+					"masterV2": struct{}{},
 				},
-				EnablingAnswerValues: AnswerDependencies{},
-				EnablingQuestions:    map[QuestionSfid]struct{}{},
-			},
-		},
-		result,
-	)
-}
-
-func TestExpand_circularDisabled(t *testing.T) {
-	_, err := expand(map[QuestionSfid]questionDependencies{
-		"q1": questionDependencies{
-			DisablingAnswerValues: AnswerDependencies{
-				"q2": "aq2",
-			},
-		},
-		"q2": questionDependencies{
-			DisablingAnswerValues: AnswerDependencies{
-				"q1": "aq1",
 			},
 		},
-	})
-
-	assert.Error(t, err)
-}
-
-func TestExpand_enablingTwoLevels(t *testing.T) {
-	result, err := expand(map[QuestionSfid]questionDependencies{
-		"q1": questionDependencies{
-			EnablingAnswerValues: AnswerDependencies{
-				"q0": "aq0",
-			},
-		},
-		"q2": questionDependencies{
-			EnablingAnswerValues: AnswerDependencies{
-				"q1": "aq1",
-			},
-		},
-		"q3": questionDependencies{
-			EnablingAnswerValues: AnswerDependencies{
-				"q2": "aq2",
-			},
-		},
-	})
-
-	assert.NoError(t, err)
-	assert.Equal(t,
-		map[QuestionSfid]questionDependencies{
-			"q1": questionDependencies{
-				EnablingAnswerValues: AnswerDependencies{
-					"q0": "aq0",
-				},
-				DisablingAnswerValues: AnswerDependencies{},
-				EnablingQuestions:     map[QuestionSfid]struct{}{},
-			},
-			"q2": questionDependencies{
-				EnablingAnswerValues: AnswerDependencies{
-					"q0": "aq0",
-					"q1": "aq1",
-				},
-				DisablingAnswerValues: AnswerDependencies{},
-				EnablingQuestions:     map[QuestionSfid]struct{}{},
-			},
-			"q3": questionDependencies{
-				EnablingAnswerValues: AnswerDependencies{
-					"q0": "aq0",
-					"q1": "aq1",
-					"q2": "aq2",
-				},
-				DisablingAnswerValues: AnswerDependencies{},
-				EnablingQuestions:     map[QuestionSfid]struct{}{},
+		AnswerDependencies{},
+		AnswerDependencies{
+			"masterQ": AnswerValues{
+				"masterV1": struct{}{},
+				"masterV2": struct{}{},
+				"masterV3": struct{}{},
 			},
 		},
-		result,
+		QuestionDependencies{},
 	)
+	assert.True(t, enabled)
 }
 
-func TestExpand_circularEnabled(t *testing.T) {
-	_, err := expand(map[QuestionSfid]questionDependencies{
-		"q1": questionDependencies{
-			EnablingAnswerValues: AnswerDependencies{
-				"q2": "aq2",
-			},
-		},
-		"q2": questionDependencies{
-			EnablingAnswerValues: AnswerDependencies{
-				"q1": "aq1",
-			},
-		},
-	})
-
-	assert.Error(t, err)
-}
-
-func TestExpand_enablingQuestionTwoLevels(t *testing.T) {
-	result, err := expand(map[QuestionSfid]questionDependencies{
-		"q1": questionDependencies{
-			EnablingQuestions: map[QuestionSfid]struct{}{
-				"q0": struct{}{},
-			},
-		},
-		"q2": questionDependencies{
-			EnablingQuestions: map[QuestionSfid]struct{}{
-				"q1": struct{}{},
-			},
-		},
-		"q3": questionDependencies{
-			EnablingQuestions: map[QuestionSfid]struct{}{
-				"q2": struct{}{},
-			},
-		},
-	})
-
-	assert.NoError(t, err)
-	assert.Equal(t,
-		map[QuestionSfid]questionDependencies{
-			"q1": questionDependencies{
-				EnablingAnswerValues:  AnswerDependencies{},
-				DisablingAnswerValues: AnswerDependencies{},
-				EnablingQuestions: map[QuestionSfid]struct{}{
-					"q0": struct{}{},
-				},
-			},
-			"q2": questionDependencies{
-				EnablingAnswerValues:  AnswerDependencies{},
-				DisablingAnswerValues: AnswerDependencies{},
-				EnablingQuestions: map[QuestionSfid]struct{}{
-					"q0": struct{}{},
-					"q1": struct{}{},
-				},
-			},
-			"q3": questionDependencies{
-				EnablingAnswerValues:  AnswerDependencies{},
-				DisablingAnswerValues: AnswerDependencies{},
-				EnablingQuestions: map[QuestionSfid]struct{}{
-					"q0": struct{}{},
-					"q1": struct{}{},
-					"q2": struct{}{},
+func TestDisablingAnswerValuesAnyMatched(t *testing.T) {
+	enabled := Enable(
+		Responses{
+			"masterQ": Response{
+				Answers: map[AnswerValueSfid]struct{}{
+					"masterV3": struct{}{},
 				},
 			},
 		},
-		result,
-	)
-}
-
-func TextExpandMixedTypes(t *testing.T) {
-	result, err := expand(map[QuestionSfid]questionDependencies{
-		"q1": questionDependencies{
-			EnablingAnswerValues: AnswerDependencies{
-				"q0": "aq0",
-			},
-		},
-		"q2": questionDependencies{
-			DisablingAnswerValues: AnswerDependencies{
-				"q1": "aq1",
-			},
-		},
-		"q3": questionDependencies{
-			EnablingQuestions: map[QuestionSfid]struct{}{
-				"q2": struct{}{},
-			},
-		},
-	})
-
-	assert.NoError(t, err)
-	assert.Equal(t,
-		map[QuestionSfid]questionDependencies{
-			"q1": questionDependencies{
-				EnablingAnswerValues: AnswerDependencies{
-					"q0": "aq0",
-				},
-				DisablingAnswerValues: AnswerDependencies{},
-				EnablingQuestions:     map[QuestionSfid]struct{}{},
-			},
-			"q2": questionDependencies{
-				EnablingAnswerValues: AnswerDependencies{
-					"q0": "aq0",
-				},
-				DisablingAnswerValues: AnswerDependencies{
-					"q1": "aq1",
-				},
-				EnablingQuestions: map[QuestionSfid]struct{}{},
-			},
-			"q3": questionDependencies{
-				EnablingAnswerValues: AnswerDependencies{
-					"q0": "aq0",
-				},
-				DisablingAnswerValues: AnswerDependencies{
-					"q1": "aq1",
-				},
-				EnablingQuestions: map[QuestionSfid]struct{}{
-					"q2": struct{}{},
-				},
+		AnswerDependencies{
+			"masterQ": AnswerValues{
+				"masterV1": struct{}{},
+				"masterV3": struct{}{},
 			},
 		},
-		result,
+		AnswerDependencies{},
+		QuestionDependencies{},
 	)
-}
-
-func TestExpand_enablingQuestionCircular(t *testing.T) {
-	_, err := expand(map[QuestionSfid]questionDependencies{
-		"q1": questionDependencies{
-			EnablingQuestions: map[QuestionSfid]struct{}{
-				"q2": struct{}{},
-			},
-		},
-		"q2": questionDependencies{
-			EnablingQuestions: map[QuestionSfid]struct{}{
-				"q1": struct{}{},
-			},
-		},
-	})
-
-	assert.Error(t, err)
+	assert.False(t, enabled)
 }
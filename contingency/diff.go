@@ -0,0 +1,143 @@
+package contingency
+
+import (
+	"reflect"
+	"sort"
+)
+
+//RuleChange to a question's rules between two versions of the question bank
+type RuleChange struct {
+	Question QuestionSfid
+	Type     ChangeType
+	Added    []Rule
+	Removed  []Rule
+	//ConditionChanged when the question's condition differs, beyond the questions it references
+	ConditionChanged bool
+}
+
+//ChangeType of a RuleChange
+type ChangeType string
+
+const (
+	//GainedRules question had no rules
+	GainedRules ChangeType = "gained"
+	//LostRules question no longer has any rules
+	LostRules ChangeType = "lost"
+	//ChangedRules question had rules and still does
+	ChangedRules ChangeType = "changed"
+)
+
+//Diff the rules of each question between previous and next, sorted by question
+func Diff(previous Definitions, next Definitions) []RuleChange {
+	previousRules := rulesByQuestion(previous.Rules())
+	nextRules := rulesByQuestion(next.Rules())
+
+	questions := map[QuestionSfid]struct{}{}
+	for q := range previousRules {
+		questions[q] = struct{}{}
+	}
+	for q := range nextRules {
+		questions[q] = struct{}{}
+	}
+
+	result := []RuleChange{}
+	for _, q := range sortedQuestions(questions) {
+		change := RuleChange{
+			Question:         q,
+			Added:            subtractRules(nextRules[q], previousRules[q]),
+			Removed:          subtractRules(previousRules[q], nextRules[q]),
+			ConditionChanged: !reflect.DeepEqual(previous.Conditions[q], next.Conditions[q]),
+		}
+		if len(change.Added) == 0 && len(change.Removed) == 0 && !change.ConditionChanged {
+			continue
+		}
+
+		switch {
+		case len(previousRules[q]) == 0:
+			change.Type = GainedRules
+		case len(nextRules[q]) == 0:
+			change.Type = LostRules
+		default:
+			change.Type = ChangedRules
+		}
+		result = append(result, change)
+	}
+
+	return result
+}
+
+//AssessmentImpact of migrating an assessment to a new version of the question bank
+type AssessmentImpact struct {
+	Assessment string
+	//Appeared questions hidden before and shown after migrating, sorted
+	Appeared []QuestionSfid
+	//Disappeared questions shown before and hidden after migrating, sorted
+	Disappeared []QuestionSfid
+}
+
+//Impact on the visibility of questions for each assessment's responses, keyed by assessment,
+//listing only assessments which would see a change, sorted by assessment
+func Impact(previous *Engine, next *Engine, assessments map[string]Responses) []AssessmentImpact {
+	questions := map[QuestionSfid]struct{}{}
+	for q := range previous.questions {
+		questions[q] = struct{}{}
+	}
+	for q := range next.questions {
+		questions[q] = struct{}{}
+	}
+	sorted := sortedQuestions(questions)
+
+	ids := make([]string, 0, len(assessments))
+	for id := range assessments {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+
+	result := []AssessmentImpact{}
+	for _, id := range ids {
+		responses := assessments[id]
+		impact := AssessmentImpact{
+			Assessment:  id,
+			Appeared:    []QuestionSfid{},
+			Disappeared: []QuestionSfid{},
+		}
+		for _, q := range sorted {
+			before := previous.Visible(q, responses)
+			after := next.Visible(q, responses)
+			if !before && after {
+				impact.Appeared = append(impact.Appeared, q)
+			} else if before && !after {
+				impact.Disappeared = append(impact.Disappeared, q)
+			}
+		}
+		if len(impact.Appeared) > 0 || len(impact.Disappeared) > 0 {
+			result = append(result, impact)
+		}
+	}
+
+	return result
+}
+
+func rulesByQuestion(rules []Rule) map[QuestionSfid][]Rule {
+	result := map[QuestionSfid][]Rule{}
+	for _, rule := range rules {
+		result[rule.Question] = append(result[rule.Question], rule)
+	}
+	return result
+}
+
+//subtractRules rules in a which aren't in b, keeping the order of a
+func subtractRules(a []Rule, b []Rule) []Rule {
+	existing := map[Rule]struct{}{}
+	for _, rule := range b {
+		existing[rule] = struct{}{}
+	}
+
+	result := []Rule{}
+	for _, rule := range a {
+		if _, ok := existing[rule]; !ok {
+			result = append(result, rule)
+		}
+	}
+	return result
+}
@@ -0,0 +1,147 @@
+package contingency
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDiff(t *testing.T) {
+	previous := Definitions{
+		Goals: []Goal{
+			{
+				MasterQuestion: questionPtr("q0"),
+				AnswerValue:    answerValuePtr("yes"),
+				Questions:      []QuestionSfid{"q1", "q2"},
+			},
+		},
+		Conditions: map[QuestionSfid]Condition{
+			"q4": Number{Question: "q0", Operator: Greater, Value: 1},
+		},
+	}
+	next := Definitions{
+		Goals: []Goal{
+			{
+				MasterQuestion: questionPtr("q0"),
+				AnswerValue:    answerValuePtr("yes"),
+				Questions:      []QuestionSfid{"q1"},
+			},
+			{
+				MasterQuestion: questionPtr("q0"),
+				AnswerValue:    answerValuePtr("no"),
+				Questions:      []QuestionSfid{"q1", "q3"},
+				Disables:       true,
+			},
+		},
+		Conditions: map[QuestionSfid]Condition{
+			"q4": Number{Question: "q0", Operator: Greater, Value: 5},
+		},
+	}
+
+	assert.Equal(t,
+		[]RuleChange{
+			{
+				Question: "q1",
+				Type:     ChangedRules,
+				Added:    []Rule{{Question: "q1", Type: DisablingAnswerValueRule, Master: "q0", AnswerValue: "no"}},
+				Removed:  []Rule{},
+			},
+			{
+				Question: "q2",
+				Type:     LostRules,
+				Added:    []Rule{},
+				Removed:  []Rule{{Question: "q2", Type: EnablingAnswerValueRule, Master: "q0", AnswerValue: "yes"}},
+			},
+			{
+				Question: "q3",
+				Type:     GainedRules,
+				Added:    []Rule{{Question: "q3", Type: DisablingAnswerValueRule, Master: "q0", AnswerValue: "no"}},
+				Removed:  []Rule{},
+			},
+			{
+				Question:         "q4",
+				Type:             ChangedRules,
+				Added:            []Rule{},
+				Removed:          []Rule{},
+				ConditionChanged: true,
+			},
+		},
+		Diff(previous, next),
+	)
+}
+
+func TestDiff_Unchanged(t *testing.T) {
+	defs := Definitions{
+		Goals: []Goal{
+			{
+				MasterQuestion: questionPtr("q0"),
+				AnswerValue:    answerValuePtr("yes"),
+				Questions:      []QuestionSfid{"q1"},
+			},
+		},
+	}
+
+	assert.Equal(t, []RuleChange{}, Diff(defs, defs))
+}
+
+func TestImpact(t *testing.T) {
+	previous, err := NewEngine(Definitions{
+		Goals: []Goal{
+			{
+				MasterQuestion: questionPtr("q0"),
+				AnswerValue:    answerValuePtr("yes"),
+				Questions:      []QuestionSfid{"q1", "q2"},
+			},
+		},
+	})
+	assert.NoError(t, err)
+
+	next, err := NewEngine(Definitions{
+		Goals: []Goal{
+			{
+				MasterQuestion: questionPtr("q0"),
+				AnswerValue:    answerValuePtr("yes"),
+				Questions:      []QuestionSfid{"q1"},
+			},
+			{
+				MasterQuestion: questionPtr("q0"),
+				AnswerValue:    answerValuePtr("yes"),
+				Questions:      []QuestionSfid{"q3"},
+				Disables:       true,
+			},
+		},
+	})
+	assert.NoError(t, err)
+
+	assert.Equal(t,
+		[]AssessmentImpact{
+			{
+				Assessment:  "a1",
+				Appeared:    []QuestionSfid{"q2"},
+				Disappeared: []QuestionSfid{},
+			},
+			{
+				Assessment:  "a2",
+				Appeared:    []QuestionSfid{},
+				Disappeared: []QuestionSfid{"q3"},
+			},
+			{
+				Assessment:  "a3",
+				Appeared:    []QuestionSfid{"q2"},
+				Disappeared: []QuestionSfid{},
+			},
+		},
+		Impact(previous, next, map[string]Responses{
+			"a1": Responses{},
+			"a2": Responses{"q0": Response{Answers: answers("yes")}},
+			"a3": Responses{"q0": Response{Answers: answers("no")}},
+		}),
+	)
+
+	assert.Equal(t,
+		[]AssessmentImpact{},
+		Impact(previous, previous, map[string]Responses{
+			"a1": Responses{},
+		}),
+	)
+}
@@ -0,0 +1,341 @@
+package contingency
+
+import "github.com/pkg/errors"
+
+//Goal shows or hides its questions based on an answer value of a master question
+type Goal struct {
+	MasterQuestion *QuestionSfid
+	AnswerValue    *AnswerValueSfid
+	Questions      []QuestionSfid
+	Disables       bool
+}
+
+//Definitions of every contingency in the question bank
+type Definitions struct {
+	Goals []Goal
+	//EnablingQuestions question to the questions enabling it
+	EnablingQuestions map[QuestionSfid][]EnablingQuestion
+	//Conditions question to an additional condition which must be met for it to be shown,
+	//inherited by questions depending on it through Goals or EnablingQuestions
+	Conditions map[QuestionSfid]Condition
+}
+
+//EnablingQuestion enables its dependent question once its response meets Threshold,
+//DefaultThreshold when unset
+type EnablingQuestion struct {
+	Question  QuestionSfid
+	Threshold Threshold
+}
+
+//Engine determines the visibility of the questions of an assessment.  A question is visible when
+//its own rules are met and every master question it depends on is visible.
+type Engine struct {
+	questions map[QuestionSfid]struct{}
+	//direct dependencies of each question, before expansion
+	direct map[QuestionSfid]questionDependencies
+	//ownConditions as defined, before inheriting those of ancestors
+	ownConditions map[QuestionSfid]Condition
+	//masters question to the questions its own rules and condition refer to
+	masters map[QuestionSfid][]QuestionSfid
+	//own question to the condition of its own rules and condition, ignoring its ancestors
+	own map[QuestionSfid]Condition
+	//closures question to every question it transitively depends on
+	closures   map[QuestionSfid]map[QuestionSfid]struct{}
+	conditions map[QuestionSfid]Condition
+	//dependents question to the questions whose visibility depends on its response
+	dependents map[QuestionSfid][]QuestionSfid
+}
+
+//ErrInvalidDefinitions when a goal has a master question but no answer value, or a condition is missing
+var ErrInvalidDefinitions = errors.New("invalid contingency definitions")
+
+//Validate returns ErrInvalidDefinitions for definitions which can't be loaded
+func (d Definitions) Validate() error {
+	for i, goal := range d.Goals {
+		if goal.MasterQuestion != nil && goal.AnswerValue == nil {
+			return errors.Wrapf(ErrInvalidDefinitions, "goal %d of %v has no answer value", i, *goal.MasterQuestion)
+		}
+	}
+	for _, q := range sortedConditionQuestions(d.Conditions) {
+		if d.Conditions[q] == nil {
+			return errors.Wrapf(ErrInvalidDefinitions, "condition of %v is missing", q)
+		}
+	}
+	return nil
+}
+
+//NewEngine loads and expands all contingency definitions
+func NewEngine(defs Definitions) (*Engine, error) {
+	err := defs.Validate()
+	if err != nil {
+		return nil, err
+	}
+
+	direct := fromDefinitions(defs)
+
+	questions := map[QuestionSfid]struct{}{}
+	masterSets := map[QuestionSfid]map[QuestionSfid]struct{}{}
+	for q, deps := range direct {
+		questions[q] = struct{}{}
+		masterSets[q] = ancestors(deps)
+	}
+	for q, condition := range defs.Conditions {
+		questions[q] = struct{}{}
+		if _, ok := masterSets[q]; !ok {
+			masterSets[q] = map[QuestionSfid]struct{}{}
+		}
+		addConditionQuestions(condition, masterSets[q])
+	}
+
+	masters := make(map[QuestionSfid][]QuestionSfid, len(masterSets))
+	for q, set := range masterSets {
+		masters[q] = sortedQuestions(set)
+		for master := range set {
+			questions[master] = struct{}{}
+		}
+	}
+
+	own := map[QuestionSfid]Condition{}
+	for q := range masters {
+		deps, hasDeps := direct[q]
+		own[q] = ownCondition(deps, hasDeps, defs.Conditions[q])
+	}
+
+	closures := map[QuestionSfid]map[QuestionSfid]struct{}{}
+	for _, q := range sortedQuestions(questions) {
+		err := addAncestorClosure(q, masters, []QuestionSfid{}, closures)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	//visible only when the question's own rules and those of all its ancestors are met
+	conditions := map[QuestionSfid]Condition{}
+	for q, ownCondition := range own {
+		conditions[q] = append(And{ownCondition}, inheritedConditions(q, own, closures)...)
+	}
+
+	return &Engine{
+		questions:     questions,
+		direct:        direct,
+		ownConditions: defs.Conditions,
+		masters:       masters,
+		own:           own,
+		closures:      closures,
+		conditions:    conditions,
+		dependents:    reverseIndex(conditions),
+	}, nil
+}
+
+//ownCondition met when the dependencies of a question, if it has any, and its condition, if any, are met
+func ownCondition(deps questionDependencies, hasDeps bool, condition Condition) Condition {
+	result := And{}
+	if hasDeps {
+		result = append(result, FromDependencies(
+			deps.DisablingAnswerValues,
+			deps.EnablingAnswerValues,
+			deps.EnablingQuestions,
+		))
+	}
+	if condition != nil {
+		result = append(result, condition)
+	}
+	return result
+}
+
+//inheritedConditions the own conditions of each of q's ancestors, in question order
+func inheritedConditions(
+	q QuestionSfid,
+	own map[QuestionSfid]Condition,
+	closures map[QuestionSfid]map[QuestionSfid]struct{},
+) []Condition {
+
+	result := []Condition{}
+	for _, ancestor := range sortedQuestions(closures[q]) {
+		if ancestorCondition, ok := own[ancestor]; ok {
+			result = append(result, ancestorCondition)
+		}
+	}
+	return result
+}
+
+//addAncestorClosure adds every question q transitively depends on to closures[q].
+//path holds the questions being visited, leading to q; reaching one of them again is a cycle.
+func addAncestorClosure(
+	q QuestionSfid,
+	masters map[QuestionSfid][]QuestionSfid,
+	path []QuestionSfid,
+	closures map[QuestionSfid]map[QuestionSfid]struct{},
+) error {
+	for i, onPath := range path {
+		if onPath == q {
+			cycle := make([]QuestionSfid, 0, len(path)-i+1)
+			cycle = append(cycle, path[i:]...)
+			return &CycleError{Path: append(cycle, q)}
+		}
+	}
+
+	if _, alreadyDone := closures[q]; alreadyDone {
+		return nil
+	}
+
+	result := map[QuestionSfid]struct{}{}
+	path = append(path, q)
+	for _, master := range masters[q] {
+		err := addAncestorClosure(master, masters, path, closures)
+		if err != nil {
+			return err
+		}
+		result[master] = struct{}{}
+		for ancestor := range closures[master] {
+			result[ancestor] = struct{}{}
+		}
+	}
+
+	closures[q] = result
+	return nil
+}
+
+//Visible determines if question should be shown, questions without contingencies are always shown
+func (e *Engine) Visible(question QuestionSfid, responses Responses) bool {
+	condition, ok := e.conditions[question]
+	if !ok {
+		return true
+	}
+
+	return condition.Met(responses)
+}
+
+//Visibility of every question with, or referenced by, a contingency
+func (e *Engine) Visibility(responses Responses) map[QuestionSfid]bool {
+	result := make(map[QuestionSfid]bool, len(e.questions))
+	for q := range e.questions {
+		result[q] = e.Visible(q, responses)
+	}
+	return result
+}
+
+//Dependents are the questions whose visibility depends on the response to question, directly or transitively
+func (e *Engine) Dependents(question QuestionSfid) []QuestionSfid {
+	return e.dependents[question]
+}
+
+//Apply sets the response to question, returning the new visibility of each question it shows or hides
+func (e *Engine) Apply(responses Responses, question QuestionSfid, response Response) map[QuestionSfid]bool {
+	dependents := e.dependents[question]
+
+	before := make([]bool, len(dependents))
+	for i, q := range dependents {
+		before[i] = e.Visible(q, responses)
+	}
+
+	responses[question] = response
+
+	result := map[QuestionSfid]bool{}
+	for i, q := range dependents {
+		visible := e.Visible(q, responses)
+		if visible != before[i] {
+			result[q] = visible
+		}
+	}
+	return result
+}
+
+//reverseIndex maps each question referenced by a condition to the questions whose conditions reference it.
+//Conditions are already expanded through ancestors, so the index is transitive.
+func reverseIndex(conditions map[QuestionSfid]Condition) map[QuestionSfid][]QuestionSfid {
+	dependents := map[QuestionSfid]map[QuestionSfid]struct{}{}
+	for q, condition := range conditions {
+		referenced := map[QuestionSfid]struct{}{}
+		addConditionQuestions(condition, referenced)
+		for master := range referenced {
+			if _, ok := dependents[master]; !ok {
+				dependents[master] = map[QuestionSfid]struct{}{}
+			}
+			dependents[master][q] = struct{}{}
+		}
+	}
+
+	result := make(map[QuestionSfid][]QuestionSfid, len(dependents))
+	for master, questions := range dependents {
+		result[master] = sortedQuestions(questions)
+	}
+	return result
+}
+
+//ancestors are the questions deps refers to
+func ancestors(deps questionDependencies) map[QuestionSfid]struct{} {
+	result := map[QuestionSfid]struct{}{}
+	for master := range deps.DisablingAnswerValues {
+		result[master] = struct{}{}
+	}
+	for master := range deps.EnablingAnswerValues {
+		result[master] = struct{}{}
+	}
+	for enablingQuestion := range deps.EnablingQuestions {
+		result[enablingQuestion] = struct{}{}
+	}
+	return result
+}
+
+func fromDefinitions(defs Definitions) map[QuestionSfid]questionDependencies {
+	result := map[QuestionSfid]questionDependencies{}
+
+	for _, goal := range defs.Goals {
+		goalType := answerValueEnables
+		if goal.Disables {
+			goalType = answerValueDisables
+		}
+
+		for q, deps := range fromGoal(goal.MasterQuestion, goal.AnswerValue, goal.Questions, goalType) {
+			mergeDependencies(q, result, deps)
+		}
+	}
+
+	for q, enablingQuestions := range defs.EnablingQuestions {
+		deps := questionDependencies{
+			DisablingAnswerValues: AnswerDependencies{},
+			EnablingAnswerValues:  AnswerDependencies{},
+			EnablingQuestions:     QuestionDependencies{},
+		}
+		for _, enablingQuestion := range enablingQuestions {
+			addThresholds(deps.EnablingQuestions, enablingQuestion.Question, Thresholds{enablingQuestion.Threshold.orDefault(): struct{}{}})
+		}
+
+		mergeDependencies(q, result, deps)
+	}
+
+	return result
+}
+
+func mergeDependencies(
+	q QuestionSfid,
+	result map[QuestionSfid]questionDependencies,
+	deps questionDependencies,
+) {
+	existing, ok := result[q]
+	if !ok {
+		result[q] = deps
+		return
+	}
+
+	for master, answerValues := range deps.DisablingAnswerValues {
+		addAnswerValues(existing.DisablingAnswerValues, master, answerValues)
+	}
+
+	for master, answerValues := range deps.EnablingAnswerValues {
+		addAnswerValues(existing.EnablingAnswerValues, master, answerValues)
+	}
+
+	for enablingQuestion, thresholds := range deps.EnablingQuestions {
+		addThresholds(existing.EnablingQuestions, enablingQuestion, thresholds)
+	}
+}
+
+func sortedConditionQuestions(conditions map[QuestionSfid]Condition) []QuestionSfid {
+	questions := map[QuestionSfid]struct{}{}
+	for q := range conditions {
+		questions[q] = struct{}{}
+	}
+	return sortedQuestions(questions)
+}
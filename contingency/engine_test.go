@@ -0,0 +1,442 @@
+package contingency
+
+import (
+	"testing"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+)
+
+func questionPtr(q QuestionSfid) *QuestionSfid {
+	return &q
+}
+
+func answerValuePtr(a AnswerValueSfid) *AnswerValueSfid {
+	return &a
+}
+
+func answers(values ...AnswerValueSfid) map[AnswerValueSfid]struct{} {
+	result := map[AnswerValueSfid]struct{}{}
+	for _, v := range values {
+		result[v] = struct{}{}
+	}
+	return result
+}
+
+func TestEngine_Empty(t *testing.T) {
+	engine, err := NewEngine(Definitions{})
+
+	assert.NoError(t, err)
+	assert.Equal(t, map[QuestionSfid]bool{}, engine.Visibility(Responses{}))
+	assert.True(t, engine.Visible("q1", Responses{}))
+}
+
+func TestEngine_Visibility(t *testing.T) {
+	engine, err := NewEngine(Definitions{
+		Goals: []Goal{
+			{
+				MasterQuestion: questionPtr("q0"),
+				AnswerValue:    answerValuePtr("yes"),
+				Questions:      []QuestionSfid{"q1", "q2"},
+			},
+			{
+				MasterQuestion: questionPtr("q1"),
+				AnswerValue:    answerValuePtr("no"),
+				Questions:      []QuestionSfid{"q3"},
+				Disables:       true,
+			},
+		},
+		EnablingQuestions: map[QuestionSfid][]EnablingQuestion{
+			"q4": {{Question: "q2"}},
+		},
+	})
+	assert.NoError(t, err)
+
+	assert.Equal(t,
+		map[QuestionSfid]bool{
+			"q0": true,
+			"q1": false,
+			"q2": false,
+			"q3": false,
+			"q4": false,
+		},
+		engine.Visibility(Responses{}),
+	)
+
+	assert.Equal(t,
+		map[QuestionSfid]bool{
+			"q0": true,
+			"q1": true,
+			"q2": true,
+			"q3": true,
+			"q4": true,
+		},
+		engine.Visibility(Responses{
+			"q0": Response{Answers: answers("yes")},
+			"q2": Response{ValuePercentage: 100},
+		}),
+	)
+
+	assert.Equal(t,
+		map[QuestionSfid]bool{
+			"q0": true,
+			"q1": true,
+			"q2": true,
+			"q3": false,
+			"q4": false,
+		},
+		engine.Visibility(Responses{
+			"q0": Response{Answers: answers("yes")},
+			"q1": Response{Answers: answers("no")},
+			"q2": Response{ValuePercentage: 50},
+		}),
+	)
+}
+
+func TestEngine_MergesGoals(t *testing.T) {
+	engine, err := NewEngine(Definitions{
+		Goals: []Goal{
+			{
+				MasterQuestion: questionPtr("q0"),
+				AnswerValue:    answerValuePtr("yes"),
+				Questions:      []QuestionSfid{"q2"},
+			},
+			{
+				MasterQuestion: questionPtr("q1"),
+				AnswerValue:    answerValuePtr("yes"),
+				Questions:      []QuestionSfid{"q2"},
+			},
+		},
+	})
+	assert.NoError(t, err)
+
+	assert.True(t, engine.Visible("q2", Responses{
+		"q1": Response{Answers: answers("yes")},
+	}))
+	assert.False(t, engine.Visible("q2", Responses{}))
+}
+
+func TestEngine_NoMasterQuestion(t *testing.T) {
+	engine, err := NewEngine(Definitions{
+		Goals: []Goal{
+			{
+				Questions: []QuestionSfid{"q1"},
+			},
+		},
+	})
+	assert.NoError(t, err)
+
+	assert.Equal(t, map[QuestionSfid]bool{"q1": true}, engine.Visibility(Responses{}))
+}
+
+func TestEngine_MultipleAnswerValues(t *testing.T) {
+	engine, err := NewEngine(Definitions{
+		Goals: []Goal{
+			{
+				MasterQuestion: questionPtr("q0"),
+				AnswerValue:    answerValuePtr("a"),
+				Questions:      []QuestionSfid{"q1"},
+			},
+			{
+				MasterQuestion: questionPtr("q0"),
+				AnswerValue:    answerValuePtr("b"),
+				Questions:      []QuestionSfid{"q1"},
+			},
+		},
+	})
+	assert.NoError(t, err)
+
+	assert.True(t, engine.Visible("q1", Responses{"q0": Response{Answers: answers("a")}}))
+	assert.True(t, engine.Visible("q1", Responses{"q0": Response{Answers: answers("b")}}))
+	assert.False(t, engine.Visible("q1", Responses{"q0": Response{Answers: answers("c")}}))
+}
+
+func TestEngine_Circular(t *testing.T) {
+	_, err := NewEngine(Definitions{
+		Goals: []Goal{
+			{
+				MasterQuestion: questionPtr("q1"),
+				AnswerValue:    answerValuePtr("a"),
+				Questions:      []QuestionSfid{"q2"},
+			},
+			{
+				MasterQuestion: questionPtr("q2"),
+				AnswerValue:    answerValuePtr("a"),
+				Questions:      []QuestionSfid{"q1"},
+			},
+		},
+	})
+
+	assert.Equal(t, ErrCircularContingencies, errors.Cause(err))
+}
+
+func TestEngine_CircularPath(t *testing.T) {
+	_, err := NewEngine(Definitions{
+		Goals: []Goal{
+			{
+				MasterQuestion: questionPtr("q4"),
+				AnswerValue:    answerValuePtr("a"),
+				Questions:      []QuestionSfid{"q1"},
+			},
+			{
+				MasterQuestion: questionPtr("q9"),
+				AnswerValue:    answerValuePtr("a"),
+				Questions:      []QuestionSfid{"q4"},
+				Disables:       true,
+			},
+		},
+		EnablingQuestions: map[QuestionSfid][]EnablingQuestion{
+			"q9": {{Question: "q1"}},
+		},
+	})
+
+	assert.Equal(t, &CycleError{Path: []QuestionSfid{"q1", "q4", "q9", "q1"}}, err)
+	assert.EqualError(t, err, "circular contingencies: q1 → q4 → q9 → q1")
+}
+
+func TestEngine_CircularSelf(t *testing.T) {
+	_, err := NewEngine(Definitions{
+		Goals: []Goal{
+			{
+				MasterQuestion: questionPtr("q1"),
+				AnswerValue:    answerValuePtr("a"),
+				Questions:      []QuestionSfid{"q1"},
+			},
+		},
+	})
+
+	assert.Equal(t, &CycleError{Path: []QuestionSfid{"q1", "q1"}}, err)
+}
+
+func TestEngine_HiddenMaster(t *testing.T) {
+	engine, err := NewEngine(Definitions{
+		Goals: []Goal{
+			{
+				MasterQuestion: questionPtr("q1"),
+				AnswerValue:    answerValuePtr("a"),
+				Questions:      []QuestionSfid{"q2"},
+			},
+			{
+				MasterQuestion: questionPtr("q2"),
+				AnswerValue:    answerValuePtr("b"),
+				Questions:      []QuestionSfid{"q3"},
+			},
+		},
+	})
+	assert.NoError(t, err)
+
+	assert.False(t, engine.Visible("q3", Responses{
+		"q1": Response{Answers: answers("a")},
+	}), "own rule unmet")
+
+	assert.False(t, engine.Visible("q3", Responses{
+		"q2": Response{Answers: answers("b")},
+	}), "master hidden")
+
+	assert.True(t, engine.Visible("q3", Responses{
+		"q1": Response{Answers: answers("a")},
+		"q2": Response{Answers: answers("b")},
+	}))
+}
+
+func TestEngine_HiddenConditionMaster(t *testing.T) {
+	engine, err := NewEngine(Definitions{
+		Goals: []Goal{
+			{
+				MasterQuestion: questionPtr("q1"),
+				AnswerValue:    answerValuePtr("a"),
+				Questions:      []QuestionSfid{"q4"},
+			},
+		},
+		Conditions: map[QuestionSfid]Condition{
+			"q5": AnswerSelected{Question: "q4", AnswerValue: "y"},
+		},
+	})
+	assert.NoError(t, err)
+
+	assert.False(t, engine.Visible("q5", Responses{
+		"q4": Response{Answers: answers("y")},
+	}))
+	assert.True(t, engine.Visible("q5", Responses{
+		"q1": Response{Answers: answers("a")},
+		"q4": Response{Answers: answers("y")},
+	}))
+	assert.Equal(t, []QuestionSfid{"q4", "q5"}, engine.Dependents("q1"))
+}
+
+func TestEngine_CircularConditions(t *testing.T) {
+	_, err := NewEngine(Definitions{
+		Conditions: map[QuestionSfid]Condition{
+			"q1": AnswerSelected{Question: "q2", AnswerValue: "a"},
+			"q2": Number{Question: "q1", Operator: Greater, Value: 0},
+		},
+	})
+
+	assert.Equal(t, ErrCircularContingencies, errors.Cause(err))
+	assert.Equal(t, &CycleError{Path: []QuestionSfid{"q1", "q2", "q1"}}, err)
+}
+
+func TestEngine_Conditions(t *testing.T) {
+	engine, err := NewEngine(Definitions{
+		Goals: []Goal{
+			{
+				MasterQuestion: questionPtr("q1"),
+				AnswerValue:    answerValuePtr("yes"),
+				Questions:      []QuestionSfid{"q2"},
+			},
+		},
+		Conditions: map[QuestionSfid]Condition{
+			"q1": Number{Question: "q0", Operator: Greater, Value: 0},
+			"q3": Or{
+				AnswerSelected{Question: "q1", AnswerValue: "a"},
+				AnswerSelected{Question: "q1", AnswerValue: "b"},
+			},
+		},
+	})
+	assert.NoError(t, err)
+
+	assert.Equal(t,
+		map[QuestionSfid]bool{
+			"q0": true,
+			"q1": false,
+			"q2": false,
+			"q3": false,
+		},
+		engine.Visibility(Responses{
+			"q1": Response{Answers: answers("yes")},
+		}),
+	)
+
+	assert.Equal(t,
+		map[QuestionSfid]bool{
+			"q0": true,
+			"q1": true,
+			"q2": true,
+			"q3": true,
+		},
+		engine.Visibility(Responses{
+			"q0": Response{Number: 3},
+			"q1": Response{Answers: answers("yes", "b")},
+		}),
+	)
+}
+
+func TestEngine_EnablingQuestionThresholds(t *testing.T) {
+	engine, err := NewEngine(Definitions{
+		EnablingQuestions: map[QuestionSfid][]EnablingQuestion{
+			"q2": {
+				{
+					Question:  "q0",
+					Threshold: Threshold{Field: PercentageField, Operator: GreaterOrEqual, Value: 25},
+				},
+			},
+			"q3": {
+				{
+					Question:  "q1",
+					Threshold: Threshold{Field: CurrencyField, Operator: Greater, Value: 0},
+				},
+				{
+					Question:  "q1",
+					Threshold: Threshold{Field: NumberField, Operator: Less, Value: 50},
+				},
+			},
+		},
+	})
+	assert.NoError(t, err)
+
+	assert.Equal(t,
+		map[QuestionSfid]bool{
+			"q0": true,
+			"q1": true,
+			"q2": true,
+			"q3": true,
+		},
+		engine.Visibility(Responses{
+			"q0": Response{ValuePercentage: 25},
+			"q1": Response{Number: 60, Currency: 10.5},
+		}),
+	)
+
+	assert.Equal(t,
+		map[QuestionSfid]bool{
+			"q0": true,
+			"q1": true,
+			"q2": false,
+			"q3": false,
+		},
+		engine.Visibility(Responses{
+			"q0": Response{ValuePercentage: 24},
+			"q1": Response{Number: 50},
+		}),
+	)
+}
+
+func chainedEngine(t *testing.T) *Engine {
+	engine, err := NewEngine(Definitions{
+		Goals: []Goal{
+			{
+				MasterQuestion: questionPtr("q0"),
+				AnswerValue:    answerValuePtr("yes"),
+				Questions:      []QuestionSfid{"q1"},
+			},
+			{
+				MasterQuestion: questionPtr("q1"),
+				AnswerValue:    answerValuePtr("yes"),
+				Questions:      []QuestionSfid{"q2"},
+			},
+			{
+				MasterQuestion: questionPtr("q5"),
+				AnswerValue:    answerValuePtr("yes"),
+				Questions:      []QuestionSfid{"q6"},
+			},
+		},
+		Conditions: map[QuestionSfid]Condition{
+			"q3": Number{Question: "q0", Operator: Greater, Value: 0},
+		},
+	})
+	assert.NoError(t, err)
+	return engine
+}
+
+func TestEngine_Dependents(t *testing.T) {
+	engine := chainedEngine(t)
+
+	assert.Equal(t, []QuestionSfid{"q1", "q2", "q3"}, engine.Dependents("q0"))
+	assert.Equal(t, []QuestionSfid{"q2"}, engine.Dependents("q1"))
+	assert.Empty(t, engine.Dependents("q2"))
+}
+
+func TestEngine_Apply(t *testing.T) {
+	engine := chainedEngine(t)
+	responses := Responses{
+		"q1": Response{Answers: answers("yes")},
+		"q5": Response{Answers: answers("yes")},
+	}
+
+	flipped := engine.Apply(responses, "q0", Response{Answers: answers("yes")})
+	assert.Equal(t, map[QuestionSfid]bool{"q1": true, "q2": true}, flipped)
+	assert.Equal(t, Response{Answers: answers("yes")}, responses["q0"])
+
+	flipped = engine.Apply(responses, "q0", Response{Answers: answers("yes"), Number: 1})
+	assert.Equal(t, map[QuestionSfid]bool{"q3": true}, flipped)
+
+	flipped = engine.Apply(responses, "q0", Response{Answers: answers("no")})
+	assert.Equal(t, map[QuestionSfid]bool{"q1": false, "q2": false, "q3": false}, flipped)
+
+	flipped = engine.Apply(responses, "q4", Response{Answers: answers("no")})
+	assert.Equal(t, map[QuestionSfid]bool{}, flipped)
+}
+
+func TestNewEngine_InvalidDefinitions(t *testing.T) {
+	_, err := NewEngine(Definitions{
+		Goals: []Goal{
+			{MasterQuestion: questionPtr("q0"), Questions: []QuestionSfid{"q1"}},
+		},
+	})
+	assert.Equal(t, ErrInvalidDefinitions, errors.Cause(err))
+
+	_, err = NewEngine(Definitions{
+		Conditions: map[QuestionSfid]Condition{"q1": nil},
+	})
+	assert.Equal(t, ErrInvalidDefinitions, errors.Cause(err))
+}
@@ -0,0 +1,140 @@
+package contingency
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+//Explanation of why a question is shown or hidden
+type Explanation struct {
+	Question QuestionSfid
+	Visible  bool
+	//Reasons the question is hidden, empty when it's visible
+	Reasons []Reason
+}
+
+//Reason a question is hidden
+type Reason struct {
+	Type ReasonType
+	//Rule that wasn't satisfied, Rule.Question is the question defining it
+	Rule Rule
+	//Path from the explained question through its ancestors to Rule.Question
+	Path []QuestionSfid
+}
+
+//ReasonType why a rule hides a question
+type ReasonType string
+
+const (
+	//MissingEnablingAnswer none of the enabling answer values were selected
+	MissingEnablingAnswer ReasonType = "missing enabling answer"
+	//DisablingAnswerSelected a disabling answer value was selected
+	DisablingAnswerSelected ReasonType = "disabling answer selected"
+	//EnablingQuestionUnmet none of the enabling questions reached their threshold
+	EnablingQuestionUnmet ReasonType = "enabling question unmet"
+	//ConditionUnmet a condition wasn't met
+	ConditionUnmet ReasonType = "condition unmet"
+)
+
+func (r Reason) String() string {
+	path := make([]string, len(r.Path))
+	for i, q := range r.Path {
+		path[i] = string(q)
+	}
+	return fmt.Sprintf("%s: %s (%s)", strings.Join(path, " → "), r.Type, r.Rule)
+}
+
+//Explain why question is shown or hidden for responses, including the rules of its ancestor questions.
+//Each question's enabling answer values and enabling questions are alternatives, so they are
+//only reasons when none of that question's alternatives is met.
+func (e *Engine) Explain(question QuestionSfid, responses Responses) Explanation {
+	paths := e.ancestorPaths(question)
+	missingEnablingAnswers := []Reason{}
+	disablingAnswers := []Reason{}
+	unmetConditions := []Reason{}
+	unmetEnablingQuestions := []Reason{}
+
+	for _, owner := range sortedQuestionPaths(paths) {
+		path := paths[owner]
+
+		ownerMissingAnswers := []Reason{}
+		enabledByAnswer := false
+		ownerUnmetQuestions := []Reason{}
+		enabledByQuestion := false
+
+		rules := directRules(owner, e.direct[owner])
+		sortRules(rules)
+		for _, rule := range rules {
+			switch rule.Type {
+			case EnablingAnswerValueRule:
+				if (AnswerSelected{Question: rule.Master, AnswerValue: rule.AnswerValue}).Met(responses) {
+					enabledByAnswer = true
+				} else {
+					ownerMissingAnswers = append(ownerMissingAnswers, Reason{Type: MissingEnablingAnswer, Rule: rule, Path: path})
+				}
+			case DisablingAnswerValueRule:
+				if (AnswerSelected{Question: rule.Master, AnswerValue: rule.AnswerValue}).Met(responses) {
+					disablingAnswers = append(disablingAnswers, Reason{Type: DisablingAnswerSelected, Rule: rule, Path: path})
+				}
+			case EnablingQuestionRule:
+				if rule.Threshold.condition(rule.Master).Met(responses) {
+					enabledByQuestion = true
+				} else {
+					ownerUnmetQuestions = append(ownerUnmetQuestions, Reason{Type: EnablingQuestionUnmet, Rule: rule, Path: path})
+				}
+			}
+		}
+
+		if !enabledByAnswer {
+			missingEnablingAnswers = append(missingEnablingAnswers, ownerMissingAnswers...)
+		}
+		if condition, ok := e.ownConditions[owner]; ok && !condition.Met(responses) {
+			unmetConditions = append(unmetConditions, Reason{Type: ConditionUnmet, Rule: Rule{Question: owner, Type: ConditionRule}, Path: path})
+		}
+		if !enabledByQuestion {
+			unmetEnablingQuestions = append(unmetEnablingQuestions, ownerUnmetQuestions...)
+		}
+	}
+
+	reasons := append(missingEnablingAnswers, disablingAnswers...)
+	reasons = append(reasons, unmetConditions...)
+	reasons = append(reasons, unmetEnablingQuestions...)
+
+	return Explanation{
+		Question: question,
+		Visible:  e.Visible(question, responses),
+		Reasons:  reasons,
+	}
+}
+
+//ancestorPaths shortest path from question to itself and each of its ancestors
+func (e *Engine) ancestorPaths(question QuestionSfid) map[QuestionSfid][]QuestionSfid {
+	result := map[QuestionSfid][]QuestionSfid{question: {question}}
+	queue := []QuestionSfid{question}
+	for len(queue) > 0 {
+		q := queue[0]
+		queue = queue[1:]
+		for _, ancestor := range e.masters[q] {
+			if _, visited := result[ancestor]; visited {
+				continue
+			}
+			path := make([]QuestionSfid, len(result[q]), len(result[q])+1)
+			copy(path, result[q])
+			result[ancestor] = append(path, ancestor)
+			queue = append(queue, ancestor)
+		}
+	}
+	return result
+}
+
+//sortedQuestionPaths orders the questions of paths nearest first
+func sortedQuestionPaths(paths map[QuestionSfid][]QuestionSfid) []QuestionSfid {
+	questions := map[QuestionSfid]struct{}{}
+	for q := range paths {
+		questions[q] = struct{}{}
+	}
+	result := sortedQuestions(questions)
+	sort.SliceStable(result, func(i, j int) bool { return len(paths[result[i]]) < len(paths[result[j]]) })
+	return result
+}
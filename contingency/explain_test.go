@@ -0,0 +1,153 @@
+package contingency
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func explainEngine(t *testing.T) *Engine {
+	engine, err := NewEngine(Definitions{
+		Goals: []Goal{
+			{
+				MasterQuestion: questionPtr("q0"),
+				AnswerValue:    answerValuePtr("yes"),
+				Questions:      []QuestionSfid{"q1"},
+			},
+			{
+				MasterQuestion: questionPtr("q7"),
+				AnswerValue:    answerValuePtr("no"),
+				Questions:      []QuestionSfid{"q1"},
+				Disables:       true,
+			},
+		},
+		EnablingQuestions: map[QuestionSfid][]EnablingQuestion{
+			"q2": {{Question: "q1"}},
+		},
+		Conditions: map[QuestionSfid]Condition{
+			"q1": Number{Question: "q8", Operator: Greater, Value: 0},
+		},
+	})
+	assert.NoError(t, err)
+	return engine
+}
+
+func TestExplain_Visible(t *testing.T) {
+	engine := explainEngine(t)
+
+	explanation := engine.Explain("q2", Responses{
+		"q0": Response{Answers: answers("yes")},
+		"q1": Response{ValuePercentage: 100},
+		"q8": Response{Number: 1},
+	})
+
+	assert.Equal(t,
+		Explanation{
+			Question: "q2",
+			Visible:  true,
+			Reasons:  []Reason{},
+		},
+		explanation,
+	)
+}
+
+func TestExplain_NoContingencies(t *testing.T) {
+	engine := explainEngine(t)
+
+	assert.Equal(t,
+		Explanation{
+			Question: "q9",
+			Visible:  true,
+			Reasons:  []Reason{},
+		},
+		engine.Explain("q9", Responses{}),
+	)
+}
+
+func TestExplain_Hidden(t *testing.T) {
+	engine := explainEngine(t)
+
+	explanation := engine.Explain("q2", Responses{
+		"q0": Response{Answers: answers("no")},
+		"q1": Response{ValuePercentage: 50},
+		"q7": Response{Answers: answers("no")},
+	})
+
+	assert.Equal(t,
+		Explanation{
+			Question: "q2",
+			Visible:  false,
+			Reasons: []Reason{
+				{
+					Type: MissingEnablingAnswer,
+					Rule: Rule{Question: "q1", Type: EnablingAnswerValueRule, Master: "q0", AnswerValue: "yes"},
+					Path: []QuestionSfid{"q2", "q1"},
+				},
+				{
+					Type: DisablingAnswerSelected,
+					Rule: Rule{Question: "q1", Type: DisablingAnswerValueRule, Master: "q7", AnswerValue: "no"},
+					Path: []QuestionSfid{"q2", "q1"},
+				},
+				{
+					Type: ConditionUnmet,
+					Rule: Rule{Question: "q1", Type: ConditionRule},
+					Path: []QuestionSfid{"q2", "q1"},
+				},
+				{
+					Type: EnablingQuestionUnmet,
+					Rule: Rule{Question: "q2", Type: EnablingQuestionRule, Master: "q1", Threshold: DefaultThreshold},
+					Path: []QuestionSfid{"q2"},
+				},
+			},
+		},
+		explanation,
+	)
+}
+
+func TestExplain_AncestorEnabled(t *testing.T) {
+	engine, err := NewEngine(Definitions{
+		Goals: []Goal{
+			{
+				MasterQuestion: questionPtr("q0"),
+				AnswerValue:    answerValuePtr("yes"),
+				Questions:      []QuestionSfid{"q1"},
+			},
+			{
+				MasterQuestion: questionPtr("q1"),
+				AnswerValue:    answerValuePtr("a"),
+				Questions:      []QuestionSfid{"q2"},
+			},
+		},
+	})
+	assert.NoError(t, err)
+
+	explanation := engine.Explain("q2", Responses{
+		"q0": Response{Answers: answers("yes")},
+		"q1": Response{Answers: answers("b")},
+	})
+
+	assert.Equal(t,
+		Explanation{
+			Question: "q2",
+			Visible:  false,
+			Reasons: []Reason{
+				{
+					Type: MissingEnablingAnswer,
+					Rule: Rule{Question: "q2", Type: EnablingAnswerValueRule, Master: "q1", AnswerValue: "a"},
+					Path: []QuestionSfid{"q2"},
+				},
+			},
+		},
+		explanation,
+	)
+}
+
+func TestReasonString(t *testing.T) {
+	reason := Reason{
+		Type: DisablingAnswerSelected,
+		Rule: Rule{Question: "q1", Type: DisablingAnswerValueRule, Master: "q7", AnswerValue: "no"},
+		Path: []QuestionSfid{"q2", "q1"},
+	}
+
+	assert.Equal(t, "q2 → q1: disabling answer selected (q1 disabling answer value: q7 = no)", reason.String())
+}
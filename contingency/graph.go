@@ -0,0 +1,173 @@
+package contingency
+
+import (
+	"fmt"
+	"io"
+)
+
+//Graph of the dependencies between questions, edges pointing from master question to dependent question
+type Graph struct {
+	Questions []QuestionSfid `json:"questions"`
+	Edges     []Edge         `json:"edges"`
+}
+
+//Edge for a single Rule
+type Edge struct {
+	From    QuestionSfid `json:"from"`
+	To      QuestionSfid `json:"to"`
+	Type    RuleType     `json:"type"`
+	Label   string       `json:"label"`
+	InCycle bool         `json:"inCycle"`
+}
+
+//NewGraph of every rule in defs, marking edges which are part of a cycle
+func NewGraph(defs Definitions) (Graph, error) {
+	err := defs.Validate()
+	if err != nil {
+		return Graph{}, err
+	}
+	rules := defs.Rules()
+
+	questions := map[QuestionSfid]struct{}{}
+	adjacent := map[QuestionSfid][]QuestionSfid{}
+	for _, rule := range rules {
+		questions[rule.Question] = struct{}{}
+		questions[rule.Master] = struct{}{}
+		adjacent[rule.Master] = append(adjacent[rule.Master], rule.Question)
+	}
+
+	components := stronglyConnectedComponents(sortedQuestions(questions), adjacent)
+	componentSizes := map[int]int{}
+	for _, component := range components {
+		componentSizes[component]++
+	}
+
+	edges := make([]Edge, 0, len(rules))
+	for _, rule := range rules {
+		edge := Edge{
+			From: rule.Master,
+			To:   rule.Question,
+			Type: rule.Type,
+		}
+		switch rule.Type {
+		case EnablingAnswerValueRule, DisablingAnswerValueRule:
+			edge.Label = string(rule.AnswerValue)
+		case EnablingQuestionRule:
+			edge.Label = rule.Threshold.String()
+		}
+		edge.InCycle = rule.Master == rule.Question ||
+			(components[rule.Master] == components[rule.Question] && componentSizes[components[rule.Master]] > 1)
+		edges = append(edges, edge)
+	}
+
+	return Graph{
+		Questions: sortedQuestions(questions),
+		Edges:     edges,
+	}, nil
+}
+
+//WriteDOT writes the graph in Graphviz DOT format
+func (g Graph) WriteDOT(w io.Writer) error {
+	inCycle := map[QuestionSfid]struct{}{}
+	for _, edge := range g.Edges {
+		if edge.InCycle {
+			inCycle[edge.From] = struct{}{}
+			inCycle[edge.To] = struct{}{}
+		}
+	}
+
+	if _, err := fmt.Fprintln(w, "digraph contingencies {"); err != nil {
+		return err
+	}
+
+	for _, q := range g.Questions {
+		attributes := ""
+		if _, ok := inCycle[q]; ok {
+			attributes = " [color=red]"
+		}
+		if _, err := fmt.Fprintf(w, "\t%q%s;\n", q, attributes); err != nil {
+			return err
+		}
+	}
+
+	for _, edge := range g.Edges {
+		style := "solid"
+		switch edge.Type {
+		case DisablingAnswerValueRule:
+			style = "dashed"
+		case EnablingQuestionRule:
+			style = "dotted"
+		case ConditionRule:
+			style = "bold"
+		}
+		color := "black"
+		if edge.InCycle {
+			color = "red"
+		}
+		label := string(edge.Type)
+		if edge.Label != "" {
+			label = fmt.Sprintf("%s: %s", edge.Type, edge.Label)
+		}
+		_, err := fmt.Fprintf(w, "\t%q -> %q [label=%q, style=%s, color=%s];\n", edge.From, edge.To, label, style, color)
+		if err != nil {
+			return err
+		}
+	}
+
+	_, err := fmt.Fprintln(w, "}")
+	return err
+}
+
+//stronglyConnectedComponents numbers the component of each question using Tarjan's algorithm
+func stronglyConnectedComponents(
+	questions []QuestionSfid,
+	adjacent map[QuestionSfid][]QuestionSfid,
+) map[QuestionSfid]int {
+
+	index := map[QuestionSfid]int{}
+	lowLink := map[QuestionSfid]int{}
+	onStack := map[QuestionSfid]bool{}
+	stack := []QuestionSfid{}
+	result := map[QuestionSfid]int{}
+	components := 0
+
+	var connect func(q QuestionSfid)
+	connect = func(q QuestionSfid) {
+		index[q] = len(index)
+		lowLink[q] = index[q]
+		stack = append(stack, q)
+		onStack[q] = true
+
+		for _, next := range adjacent[q] {
+			if _, visited := index[next]; !visited {
+				connect(next)
+				if lowLink[next] < lowLink[q] {
+					lowLink[q] = lowLink[next]
+				}
+			} else if onStack[next] && index[next] < lowLink[q] {
+				lowLink[q] = index[next]
+			}
+		}
+
+		if lowLink[q] == index[q] {
+			for {
+				member := stack[len(stack)-1]
+				stack = stack[:len(stack)-1]
+				onStack[member] = false
+				result[member] = components
+				if member == q {
+					break
+				}
+			}
+			components++
+		}
+	}
+
+	for _, q := range questions {
+		if _, visited := index[q]; !visited {
+			connect(q)
+		}
+	}
+
+	return result
+}
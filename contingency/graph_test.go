@@ -0,0 +1,114 @@
+package contingency
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewGraph(t *testing.T) {
+	graph, err := NewGraph(Definitions{
+		Goals: []Goal{
+			{
+				MasterQuestion: questionPtr("q0"),
+				AnswerValue:    answerValuePtr("yes"),
+				Questions:      []QuestionSfid{"q1"},
+			},
+			{
+				MasterQuestion: questionPtr("q1"),
+				AnswerValue:    answerValuePtr("no"),
+				Questions:      []QuestionSfid{"q2"},
+				Disables:       true,
+			},
+		},
+		EnablingQuestions: map[QuestionSfid][]EnablingQuestion{
+			"q3": {{Question: "q2"}},
+		},
+	})
+	assert.NoError(t, err)
+
+	assert.Equal(t,
+		Graph{
+			Questions: []QuestionSfid{"q0", "q1", "q2", "q3"},
+			Edges: []Edge{
+				{From: "q0", To: "q1", Type: EnablingAnswerValueRule, Label: "yes"},
+				{From: "q1", To: "q2", Type: DisablingAnswerValueRule, Label: "no"},
+				{From: "q2", To: "q3", Type: EnablingQuestionRule, Label: "percentage >= 100"},
+			},
+		},
+		graph,
+	)
+}
+
+func TestNewGraph_Cycles(t *testing.T) {
+	graph, err := NewGraph(Definitions{
+		Goals: []Goal{
+			{
+				MasterQuestion: questionPtr("q1"),
+				AnswerValue:    answerValuePtr("a"),
+				Questions:      []QuestionSfid{"q2"},
+			},
+			{
+				MasterQuestion: questionPtr("q2"),
+				AnswerValue:    answerValuePtr("a"),
+				Questions:      []QuestionSfid{"q3"},
+			},
+			{
+				MasterQuestion: questionPtr("q3"),
+				AnswerValue:    answerValuePtr("a"),
+				Questions:      []QuestionSfid{"q1", "q4"},
+			},
+			{
+				MasterQuestion: questionPtr("q5"),
+				AnswerValue:    answerValuePtr("a"),
+				Questions:      []QuestionSfid{"q5"},
+			},
+		},
+	})
+	assert.NoError(t, err)
+
+	assert.Equal(t,
+		[]Edge{
+			{From: "q3", To: "q1", Type: EnablingAnswerValueRule, Label: "a", InCycle: true},
+			{From: "q1", To: "q2", Type: EnablingAnswerValueRule, Label: "a", InCycle: true},
+			{From: "q2", To: "q3", Type: EnablingAnswerValueRule, Label: "a", InCycle: true},
+			{From: "q3", To: "q4", Type: EnablingAnswerValueRule, Label: "a", InCycle: false},
+			{From: "q5", To: "q5", Type: EnablingAnswerValueRule, Label: "a", InCycle: true},
+		},
+		graph.Edges,
+	)
+}
+
+func TestGraphWriteDOT(t *testing.T) {
+	graph := Graph{
+		Questions: []QuestionSfid{"q0", "q1"},
+		Edges: []Edge{
+			{From: "q0", To: "q1", Type: DisablingAnswerValueRule, Label: "yes", InCycle: true},
+		},
+	}
+
+	var out bytes.Buffer
+	err := graph.WriteDOT(&out)
+
+	assert.NoError(t, err)
+	assert.Equal(t,
+		"digraph contingencies {\n"+
+			"\t\"q0\" [color=red];\n"+
+			"\t\"q1\" [color=red];\n"+
+			"\t\"q0\" -> \"q1\" [label=\"disabling answer value: yes\", style=dashed, color=red];\n"+
+			"}\n",
+		out.String(),
+	)
+}
+
+func TestNewGraph_GoalMissingAnswerValue(t *testing.T) {
+	_, err := NewGraph(Definitions{
+		Goals: []Goal{
+			{MasterQuestion: questionPtr("q0"), Questions: []QuestionSfid{"q1"}},
+		},
+	})
+
+	assert.Equal(t, ErrInvalidDefinitions, errors.Cause(err))
+}
@@ -0,0 +1,92 @@
+package contingency
+
+//Section of an assessment, with its questions in display order
+type Section struct {
+	Name      string
+	Questions []QuestionSfid
+}
+
+//Navigator walks the visible questions of an assessment in section order
+type Navigator struct {
+	engine   *Engine
+	sections []Section
+	order    []QuestionSfid
+	position map[QuestionSfid]int
+}
+
+//NewNavigator over sections, in order
+func NewNavigator(engine *Engine, sections []Section) *Navigator {
+	order := []QuestionSfid{}
+	position := map[QuestionSfid]int{}
+	for _, section := range sections {
+		for _, q := range section.Questions {
+			position[q] = len(order)
+			order = append(order, q)
+		}
+	}
+
+	return &Navigator{
+		engine:   engine,
+		sections: sections,
+		order:    order,
+		position: position,
+	}
+}
+
+//Next visible question after question, false when there is none or question isn't in any section
+func (n *Navigator) Next(question QuestionSfid, responses Responses) (QuestionSfid, bool) {
+	position, ok := n.position[question]
+	if !ok {
+		return "", false
+	}
+
+	for _, q := range n.order[position+1:] {
+		if n.engine.Visible(q, responses) {
+			return q, true
+		}
+	}
+	return "", false
+}
+
+//Previous visible question before question, false when there is none or question isn't in any section
+func (n *Navigator) Previous(question QuestionSfid, responses Responses) (QuestionSfid, bool) {
+	position, ok := n.position[question]
+	if !ok {
+		return "", false
+	}
+
+	for i := position - 1; i >= 0; i-- {
+		if n.engine.Visible(n.order[i], responses) {
+			return n.order[i], true
+		}
+	}
+	return "", false
+}
+
+//VisibleCounts number of visible questions in each section, by section name
+func (n *Navigator) VisibleCounts(responses Responses) map[string]int {
+	result := make(map[string]int, len(n.sections))
+	for _, section := range n.sections {
+		count := 0
+		for _, q := range section.Questions {
+			if n.engine.Visible(q, responses) {
+				count++
+			}
+		}
+		result[section.Name] += count
+	}
+	return result
+}
+
+//FirstUnanswered visible question without a response, false when every visible question has been answered
+func (n *Navigator) FirstUnanswered(responses Responses) (QuestionSfid, bool) {
+	for _, q := range n.order {
+		if _, answered := responses[q]; answered {
+			continue
+		}
+		if n.engine.Visible(q, responses) {
+			return q, true
+		}
+	}
+	return "", false
+}
@@ -0,0 +1,97 @@
+package contingency
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func testNavigator(t *testing.T) *Navigator {
+	engine, err := NewEngine(Definitions{
+		Goals: []Goal{
+			{
+				MasterQuestion: questionPtr("q1"),
+				AnswerValue:    answerValuePtr("yes"),
+				Questions:      []QuestionSfid{"q2", "q4"},
+			},
+		},
+	})
+	assert.NoError(t, err)
+
+	return NewNavigator(engine, []Section{
+		{Name: "Governance", Questions: []QuestionSfid{"q1", "q2", "q3"}},
+		{Name: "Workers", Questions: []QuestionSfid{"q4", "q5"}},
+	})
+}
+
+func TestNavigatorNext(t *testing.T) {
+	navigator := testNavigator(t)
+
+	next, ok := navigator.Next("q1", Responses{})
+	assert.True(t, ok)
+	assert.Equal(t, QuestionSfid("q3"), next)
+
+	next, ok = navigator.Next("q3", Responses{})
+	assert.True(t, ok)
+	assert.Equal(t, QuestionSfid("q5"), next)
+
+	next, ok = navigator.Next("q3", Responses{"q1": Response{Answers: answers("yes")}})
+	assert.True(t, ok)
+	assert.Equal(t, QuestionSfid("q4"), next)
+
+	_, ok = navigator.Next("q5", Responses{})
+	assert.False(t, ok)
+
+	_, ok = navigator.Next("unknown", Responses{})
+	assert.False(t, ok)
+}
+
+func TestNavigatorPrevious(t *testing.T) {
+	navigator := testNavigator(t)
+
+	previous, ok := navigator.Previous("q5", Responses{})
+	assert.True(t, ok)
+	assert.Equal(t, QuestionSfid("q3"), previous)
+
+	previous, ok = navigator.Previous("q3", Responses{"q1": Response{Answers: answers("yes")}})
+	assert.True(t, ok)
+	assert.Equal(t, QuestionSfid("q2"), previous)
+
+	_, ok = navigator.Previous("q1", Responses{})
+	assert.False(t, ok)
+}
+
+func TestNavigatorVisibleCounts(t *testing.T) {
+	navigator := testNavigator(t)
+
+	assert.Equal(t,
+		map[string]int{"Governance": 2, "Workers": 1},
+		navigator.VisibleCounts(Responses{}),
+	)
+	assert.Equal(t,
+		map[string]int{"Governance": 3, "Workers": 2},
+		navigator.VisibleCounts(Responses{"q1": Response{Answers: answers("yes")}}),
+	)
+}
+
+func TestNavigatorFirstUnanswered(t *testing.T) {
+	navigator := testNavigator(t)
+
+	first, ok := navigator.FirstUnanswered(Responses{})
+	assert.True(t, ok)
+	assert.Equal(t, QuestionSfid("q1"), first)
+
+	first, ok = navigator.FirstUnanswered(Responses{
+		"q1": Response{Answers: answers("no")},
+		"q3": Response{Number: 1},
+	})
+	assert.True(t, ok)
+	assert.Equal(t, QuestionSfid("q5"), first)
+
+	_, ok = navigator.FirstUnanswered(Responses{
+		"q1": Response{Answers: answers("no")},
+		"q3": Response{Number: 1},
+		"q5": Response{Number: 1},
+	})
+	assert.False(t, ok)
+}
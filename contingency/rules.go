@@ -0,0 +1,102 @@
+package contingency
+
+import (
+	"fmt"
+	"sort"
+)
+
+//RuleType how a rule affects its question
+type RuleType string
+
+const (
+	EnablingAnswerValueRule  RuleType = "enabling answer value"
+	DisablingAnswerValueRule RuleType = "disabling answer value"
+	EnablingQuestionRule     RuleType = "enabling question"
+	ConditionRule            RuleType = "condition"
+)
+
+//Rule is a single direct dependency of Question on Master
+type Rule struct {
+	Question QuestionSfid
+	Type     RuleType
+	Master   QuestionSfid
+	//AnswerValue of Master, for answer value rules
+	AnswerValue AnswerValueSfid
+	//Threshold Master must meet, for enabling question rules
+	Threshold Threshold
+}
+
+func (r Rule) String() string {
+	switch r.Type {
+	case EnablingAnswerValueRule, DisablingAnswerValueRule:
+		return fmt.Sprintf("%s %s: %s = %s", r.Question, r.Type, r.Master, r.AnswerValue)
+	case EnablingQuestionRule:
+		return fmt.Sprintf("%s %s: %s %s", r.Question, r.Type, r.Master, r.Threshold)
+	default:
+		return fmt.Sprintf("%s %s: %s", r.Question, r.Type, r.Master)
+	}
+}
+
+func (t Threshold) String() string {
+	return fmt.Sprintf("%s %s %v", t.Field, t.Operator, t.Value)
+}
+
+//Rules every direct dependency in defs, merged and sorted by question
+func (defs Definitions) Rules() []Rule {
+	result := []Rule{}
+
+	for q, deps := range fromDefinitions(defs) {
+		result = append(result, directRules(q, deps)...)
+	}
+
+	for q, condition := range defs.Conditions {
+		referenced := map[QuestionSfid]struct{}{}
+		addConditionQuestions(condition, referenced)
+		for master := range referenced {
+			result = append(result, Rule{Question: q, Type: ConditionRule, Master: master})
+		}
+	}
+
+	sortRules(result)
+	return result
+}
+
+//directRules flattens the direct dependencies of q into rules
+func directRules(q QuestionSfid, deps questionDependencies) []Rule {
+	result := []Rule{}
+	for master, answerValues := range deps.EnablingAnswerValues {
+		for answerValue := range answerValues {
+			result = append(result, Rule{Question: q, Type: EnablingAnswerValueRule, Master: master, AnswerValue: answerValue})
+		}
+	}
+	for master, answerValues := range deps.DisablingAnswerValues {
+		for answerValue := range answerValues {
+			result = append(result, Rule{Question: q, Type: DisablingAnswerValueRule, Master: master, AnswerValue: answerValue})
+		}
+	}
+	for master, thresholds := range deps.EnablingQuestions {
+		for threshold := range thresholds {
+			result = append(result, Rule{Question: q, Type: EnablingQuestionRule, Master: master, Threshold: threshold})
+		}
+	}
+	return result
+}
+
+func sortRules(rules []Rule) {
+	sort.Slice(rules, func(i, j int) bool {
+		a, b := rules[i], rules[j]
+		if a.Question != b.Question {
+			return a.Question < b.Question
+		}
+		if a.Type != b.Type {
+			return a.Type < b.Type
+		}
+		if a.Master != b.Master {
+			return a.Master < b.Master
+		}
+		if a.AnswerValue != b.AnswerValue {
+			return a.AnswerValue < b.AnswerValue
+		}
+		return a.Threshold.String() < b.Threshold.String()
+	})
+}
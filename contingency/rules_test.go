@@ -0,0 +1,51 @@
+package contingency
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRules(t *testing.T) {
+	rules := Definitions{
+		Goals: []Goal{
+			{
+				MasterQuestion: questionPtr("q0"),
+				AnswerValue:    answerValuePtr("yes"),
+				Questions:      []QuestionSfid{"q2", "q1"},
+			},
+			{
+				MasterQuestion: questionPtr("q1"),
+				AnswerValue:    answerValuePtr("no"),
+				Questions:      []QuestionSfid{"q2"},
+				Disables:       true,
+			},
+		},
+		EnablingQuestions: map[QuestionSfid][]EnablingQuestion{
+			"q3": {{Question: "q2"}},
+		},
+		Conditions: map[QuestionSfid]Condition{
+			"q4": Number{Question: "q3", Operator: Greater, Value: 1},
+		},
+	}.Rules()
+
+	assert.Equal(t,
+		[]Rule{
+			{Question: "q1", Type: EnablingAnswerValueRule, Master: "q0", AnswerValue: "yes"},
+			{Question: "q2", Type: DisablingAnswerValueRule, Master: "q1", AnswerValue: "no"},
+			{Question: "q2", Type: EnablingAnswerValueRule, Master: "q0", AnswerValue: "yes"},
+			{Question: "q3", Type: EnablingQuestionRule, Master: "q2", Threshold: DefaultThreshold},
+			{Question: "q4", Type: ConditionRule, Master: "q3"},
+		},
+		rules,
+	)
+}
+
+func TestRuleString(t *testing.T) {
+	assert.Equal(t, "q1 enabling answer value: q0 = yes",
+		Rule{Question: "q1", Type: EnablingAnswerValueRule, Master: "q0", AnswerValue: "yes"}.String())
+	assert.Equal(t, "q3 enabling question: q2 percentage >= 100",
+		Rule{Question: "q3", Type: EnablingQuestionRule, Master: "q2", Threshold: DefaultThreshold}.String())
+	assert.Equal(t, "q4 condition: q3",
+		Rule{Question: "q4", Type: ConditionRule, Master: "q3"}.String())
+}
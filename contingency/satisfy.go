@@ -0,0 +1,252 @@
+package contingency
+
+import "math"
+
+//term a condition which must be met, or must not be when negated, owned by the question defining it
+type term struct {
+	owner     QuestionSfid
+	condition Condition
+	negated   bool
+}
+
+//satisfiable reports if some responses meet every term.  When none do, conflicts are the
+//AnswerSelected and comparison terms contradicting each other, across every alternative tried.
+func satisfiable(terms []term) (bool, []term) {
+	return search(terms, []term{})
+}
+
+//search tries each alternative of the pending terms in turn, literals being the AnswerSelected and
+//comparison terms the alternatives taken so far require
+func search(pending []term, literals []term) (bool, []term) {
+	if len(pending) == 0 {
+		return true, nil
+	}
+
+	t, rest := pending[0], pending[1:]
+	switch condition := t.condition.(type) {
+	case And:
+		if t.negated {
+			return searchAlternatives(condition, t, rest, literals)
+		}
+		return search(append(expandTerm(condition, t), rest...), literals)
+	case Or:
+		if !t.negated {
+			return searchAlternatives(condition, t, rest, literals)
+		}
+		return search(append(expandTerm(condition, t), rest...), literals)
+	case Not:
+		negated := term{owner: t.owner, condition: condition.Condition, negated: !t.negated}
+		return search(append([]term{negated}, rest...), literals)
+	default:
+		literals = append(literals[:len(literals):len(literals)], t)
+		conflicts := contradictions(literals, t)
+		if len(conflicts) > 0 {
+			return false, conflicts
+		}
+		return search(rest, literals)
+	}
+}
+
+//searchAlternatives searches with each of conditions in place of t, until one is satisfiable
+func searchAlternatives(conditions []Condition, t term, rest []term, literals []term) (bool, []term) {
+	result := []term{}
+	for _, condition := range conditions {
+		alternative := term{owner: t.owner, condition: condition, negated: t.negated}
+		ok, conflicts := search(append([]term{alternative}, rest...), literals)
+		if ok {
+			return true, nil
+		}
+		result = append(result, conflicts...)
+	}
+	return false, result
+}
+
+//expandTerm a term for each of conditions, owned and negated like t
+func expandTerm(conditions []Condition, t term) []term {
+	result := make([]term, 0, len(conditions))
+	for _, condition := range conditions {
+		result = append(result, term{owner: t.owner, condition: condition, negated: t.negated})
+	}
+	return result
+}
+
+//contradictions among the literals on the question of added, none when they can all hold.
+//A question is answered when any of its literals must hold, otherwise leaving it unanswered meets
+//every negated literal.
+func contradictions(literals []term, added term) []term {
+	question, ok := literalQuestion(added.condition)
+	if !ok {
+		return nil
+	}
+
+	onQuestion := []term{}
+	answered := []term{}
+	for _, l := range literals {
+		if q, _ := literalQuestion(l.condition); q == question {
+			onQuestion = append(onQuestion, l)
+			if !l.negated {
+				answered = append(answered, l)
+			}
+		}
+	}
+
+	if selected, ok := added.condition.(AnswerSelected); ok {
+		for _, l := range onQuestion {
+			if l.condition == selected && l.negated != added.negated {
+				return []term{l, added}
+			}
+		}
+	}
+
+	if len(answered) == 0 {
+		return nil
+	}
+	for _, field := range []Field{PercentageField, NumberField, CurrencyField} {
+		conflicts := fieldContradictions(field, onQuestion, answered)
+		if len(conflicts) > 0 {
+			return conflicts
+		}
+	}
+	return nil
+}
+
+//fieldContradictions the comparisons of field no response to an answered question can satisfy,
+//with the literals requiring an answer when negated comparisons are among them
+func fieldContradictions(field Field, onQuestion []term, answered []term) []term {
+	result := []term{}
+	operators := []Operator{}
+	values := []float64{}
+	negatedUsed := false
+	for _, l := range onQuestion {
+		f, operator, value, ok := comparison(l.condition)
+		if !ok || f != field {
+			continue
+		}
+		if l.negated {
+			operator, ok = negateOperator(operator)
+			if !ok {
+				continue
+			}
+			negatedUsed = true
+		}
+		result = append(result, l)
+		operators = append(operators, operator)
+		values = append(values, value)
+	}
+
+	if feasible(field, operators, values) {
+		return nil
+	}
+	if negatedUsed {
+		for _, l := range answered {
+			if f, _, _, ok := comparison(l.condition); !ok || f != field {
+				result = append(result, l)
+			}
+		}
+	}
+	return result
+}
+
+//feasible if some value of field satisfies every comparison, percentages ranging from 0 to 100
+func feasible(field Field, operators []Operator, values []float64) bool {
+	lower, upper := math.Inf(-1), math.Inf(1)
+	if field == PercentageField {
+		lower, upper = 0, 100
+	}
+	lowerOpen, upperOpen := false, false
+	atLeast := func(value float64, open bool) {
+		if value > lower || (open && value == lower) {
+			lower, lowerOpen = value, open
+		}
+	}
+	atMost := func(value float64, open bool) {
+		if value < upper || (open && value == upper) {
+			upper, upperOpen = value, open
+		}
+	}
+	notEqual := []float64{}
+
+	for i, operator := range operators {
+		switch operator {
+		case GreaterOrEqual:
+			atLeast(values[i], false)
+		case Greater:
+			atLeast(values[i], true)
+		case LessOrEqual:
+			atMost(values[i], false)
+		case Less:
+			atMost(values[i], true)
+		case Equal:
+			atLeast(values[i], false)
+			atMost(values[i], false)
+		case NotEqual:
+			notEqual = append(notEqual, values[i])
+		default:
+			return false
+		}
+	}
+
+	if lower < upper {
+		return true
+	}
+	if lower > upper || lowerOpen || upperOpen {
+		return false
+	}
+	for _, value := range notEqual {
+		if value == lower {
+			return false
+		}
+	}
+	return true
+}
+
+//negateOperator the operator met exactly when operator isn't, false for unknown operators
+//which are never met, so their negation is always met
+func negateOperator(operator Operator) (Operator, bool) {
+	switch operator {
+	case GreaterOrEqual:
+		return Less, true
+	case Greater:
+		return LessOrEqual, true
+	case LessOrEqual:
+		return Greater, true
+	case Less:
+		return GreaterOrEqual, true
+	case Equal:
+		return NotEqual, true
+	case NotEqual:
+		return Equal, true
+	default:
+		return "", false
+	}
+}
+
+//literalQuestion the question an AnswerSelected or comparison condition refers to
+func literalQuestion(condition Condition) (QuestionSfid, bool) {
+	switch condition := condition.(type) {
+	case AnswerSelected:
+		return condition.Question, true
+	case Percentage:
+		return condition.Question, true
+	case Number:
+		return condition.Question, true
+	case Currency:
+		return condition.Question, true
+	default:
+		return "", false
+	}
+}
+
+//comparison the field, operator and value of a Percentage, Number or Currency condition
+func comparison(condition Condition) (Field, Operator, float64, bool) {
+	switch condition := condition.(type) {
+	case Percentage:
+		return PercentageField, condition.Operator, condition.Value, true
+	case Number:
+		return NumberField, condition.Operator, condition.Value, true
+	case Currency:
+		return CurrencyField, condition.Operator, condition.Value, true
+	default:
+		return "", "", 0, false
+	}
+}
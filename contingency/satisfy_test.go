@@ -0,0 +1,63 @@
+package contingency
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSatisfiable_UnansweredMeetsNegations(t *testing.T) {
+	ok, _ := satisfiable([]term{
+		{owner: "q1", condition: Not{Percentage{Question: "q0", Operator: GreaterOrEqual, Value: 0}}},
+		{owner: "q1", condition: Not{AnswerSelected{Question: "q0", AnswerValue: "yes"}}},
+	})
+	assert.True(t, ok)
+
+	ok, conflicts := satisfiable([]term{
+		{owner: "q1", condition: Not{Percentage{Question: "q0", Operator: GreaterOrEqual, Value: 0}}},
+		{owner: "q2", condition: AnswerSelected{Question: "q0", AnswerValue: "yes"}},
+	})
+	assert.False(t, ok)
+	assert.ElementsMatch(t,
+		[]term{
+			{owner: "q1", condition: Percentage{Question: "q0", Operator: GreaterOrEqual, Value: 0}, negated: true},
+			{owner: "q2", condition: AnswerSelected{Question: "q0", AnswerValue: "yes"}},
+		},
+		conflicts,
+	)
+}
+
+func TestSatisfiable_Alternatives(t *testing.T) {
+	ok, _ := satisfiable([]term{
+		{owner: "q1", condition: Or{
+			AnswerSelected{Question: "q0", AnswerValue: "a"},
+			AnswerSelected{Question: "q0", AnswerValue: "b"},
+		}},
+		{owner: "q2", condition: Not{AnswerSelected{Question: "q0", AnswerValue: "a"}}},
+	})
+	assert.True(t, ok)
+
+	ok, conflicts := satisfiable([]term{
+		{owner: "q1", condition: Or{
+			AnswerSelected{Question: "q0", AnswerValue: "a"},
+			AnswerSelected{Question: "q0", AnswerValue: "b"},
+		}},
+		{owner: "q2", condition: Not{Or{
+			AnswerSelected{Question: "q0", AnswerValue: "a"},
+			AnswerSelected{Question: "q0", AnswerValue: "b"},
+		}}},
+	})
+	assert.False(t, ok)
+	assert.Len(t, conflicts, 4)
+}
+
+func TestFeasible(t *testing.T) {
+	assert.True(t, feasible(PercentageField, []Operator{GreaterOrEqual}, []float64{100}))
+	assert.False(t, feasible(PercentageField, []Operator{Greater}, []float64{100}))
+	assert.True(t, feasible(NumberField, []Operator{Greater}, []float64{100}))
+	assert.False(t, feasible(NumberField, []Operator{Greater, Less}, []float64{5, 5}))
+	assert.True(t, feasible(NumberField, []Operator{GreaterOrEqual, LessOrEqual}, []float64{5, 5}))
+	assert.False(t, feasible(NumberField, []Operator{Equal, NotEqual}, []float64{5, 5}))
+	assert.True(t, feasible(CurrencyField, []Operator{GreaterOrEqual, NotEqual}, []float64{5, 5}))
+	assert.False(t, feasible(CurrencyField, []Operator{"~"}, []float64{5}))
+}
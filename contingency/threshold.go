@@ -0,0 +1,86 @@
+package contingency
+
+import "sort"
+
+//Threshold a response value must satisfy
+type Threshold struct {
+	Field    Field
+	Operator Operator
+	Value    float64
+}
+
+//Field of a Response compared against a Threshold
+type Field string
+
+const (
+	PercentageField Field = "percentage"
+	NumberField     Field = "number"
+	CurrencyField   Field = "currency"
+)
+
+//DefaultThreshold enabling questions must reach when no Threshold is given
+var DefaultThreshold = Threshold{Field: PercentageField, Operator: GreaterOrEqual, Value: 100}
+
+//Thresholds set of thresholds
+type Thresholds map[Threshold]struct{}
+
+//QuestionDependencies question to the thresholds its response may satisfy to enable the dependency
+type QuestionDependencies map[QuestionSfid]Thresholds
+
+//condition met when the response to question satisfies the threshold
+func (t Threshold) condition(question QuestionSfid) Condition {
+	switch t.Field {
+	case NumberField:
+		return Number{Question: question, Operator: t.Operator, Value: t.Value}
+	case CurrencyField:
+		return Currency{Question: question, Operator: t.Operator, Value: t.Value}
+	default:
+		return Percentage{Question: question, Operator: t.Operator, Value: t.Value}
+	}
+}
+
+//orDefault substitutes DefaultThreshold for an unset threshold
+func (t Threshold) orDefault() Threshold {
+	if t == (Threshold{}) {
+		return DefaultThreshold
+	}
+	return t
+}
+
+//addThresholds merges thresholds into the thresholds result has for q
+func addThresholds(result QuestionDependencies, q QuestionSfid, thresholds Thresholds) {
+	existing, ok := result[q]
+	if !ok {
+		existing = Thresholds{}
+		result[q] = existing
+	}
+	for threshold := range thresholds {
+		existing[threshold] = struct{}{}
+	}
+}
+
+func sortedThresholds(thresholds Thresholds) []Threshold {
+	result := make([]Threshold, 0, len(thresholds))
+	for t := range thresholds {
+		result = append(result, t)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].Field != result[j].Field {
+			return result[i].Field < result[j].Field
+		}
+		if result[i].Operator != result[j].Operator {
+			return result[i].Operator < result[j].Operator
+		}
+		return result[i].Value < result[j].Value
+	})
+	return result
+}
+
+func sortedQuestionDependencyQuestions(deps QuestionDependencies) []QuestionSfid {
+	result := make([]QuestionSfid, 0, len(deps))
+	for q := range deps {
+		result = append(result, q)
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i] < result[j] })
+	return result
+}
@@ -0,0 +1,9 @@
+-- Refresh tokens which have been exchanged, kept until they would have expired
+-- so presenting one again can be detected as reuse
+create table oauth_used_refresh_tokens (
+	id varchar(255) primary key,
+	session_id integer not null references oauth_sessions(id) on delete cascade,
+	expire_time integer not null,
+	created_at timestamp not null,
+	updated_at timestamp not null
+);
@@ -0,0 +1,14 @@
+-- Tokens issued in go are stored as keyed hashes, flagged by hashed.  Tokens issued by the
+-- PHP api, or before this migration, keep their raw ids.  The PHP api only finds tokens by
+-- their raw id, so the go proxy validates bearer tokens and forwards their stored id instead.
+alter table oauth_access_tokens
+	alter column id type varchar(255),
+	add column hashed boolean not null default false;
+
+alter table oauth_refresh_tokens
+	alter column id type varchar(255),
+	alter column access_token_id type varchar(255),
+	add column hashed boolean not null default false;
+
+alter table oauth_used_refresh_tokens
+	add column hashed boolean not null default false;
@@ -0,0 +1,16 @@
+-- Sessions issued to staff acting as another user record the staff member
+alter table oauth_sessions
+	add column impersonator_id integer;
+
+-- Every request made with an impersonation session
+create table impersonation_audit_log (
+	id serial primary key,
+	session_id integer not null,
+	impersonator_id integer not null,
+	user_id integer not null,
+	method varchar(16) not null,
+	path text not null,
+	created_at timestamp not null
+);
+
+create index impersonation_audit_log_impersonator_id on impersonation_audit_log(impersonator_id, created_at);
@@ -0,0 +1,6 @@
+-- Last request made with each session, written in batches by auth.ActivityTracker.
+-- last_seen_at is in the db's time zone, like created_at.
+alter table oauth_sessions
+	add column last_seen_at timestamp,
+	add column last_ip varchar(45),
+	add column last_user_agent text;
@@ -0,0 +1,9 @@
+-- Failed token validations per client IP and per token prefix, shared by every
+-- instance using auth.PostgresAttemptStore
+create table auth_failed_attempts (
+	key varchar(255) primary key,
+	failures integer not null,
+	last_failure timestamp not null
+);
+
+create index auth_failed_attempts_last_failure on auth_failed_attempts(last_failure);
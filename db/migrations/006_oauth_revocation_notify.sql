@@ -0,0 +1,13 @@
+-- Notifies auth_revocations with the session_id of every deleted access token, whether
+-- deleted by a Go instance, the PHP api or a cascade from oauth_sessions, so that every
+-- instance drops the session from its auth.TokenCache
+create function notify_auth_revocation() returns trigger as $$
+begin
+	perform pg_notify('auth_revocations', old.session_id::text);
+	return old;
+end;
+$$ language plpgsql;
+
+create trigger oauth_access_tokens_revoked
+	after delete on oauth_access_tokens
+	for each row execute procedure notify_auth_revocation();
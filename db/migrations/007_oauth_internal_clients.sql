@@ -0,0 +1,3 @@
+-- Clients of our own jobs, as opposed to partners.  Only these may be granted internal: scopes,
+-- such as internal:assessment:export which reaches every company's assessment.
+alter table oauth_clients add column internal boolean not null default false;
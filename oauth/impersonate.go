@@ -0,0 +1,52 @@
+package oauth
+
+import (
+	"database/sql"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/thematthopkins/impact-go/auth"
+)
+
+const impersonationLifetime = time.Hour
+
+// Impersonate issues the staff user of session an access token acting as the user_id form value.
+// Requests made with it are audited, and can't submit assessments, change credentials, or make
+// any but read requests to the PHP api.
+func Impersonate(w http.ResponseWriter, r *http.Request, db *sql.DB, session auth.Session) {
+	if r.Method != http.MethodPost {
+		w.Header().Set("Allow", http.MethodPost)
+		http.Error(w, "Method Not Allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	userID, err := strconv.Atoi(r.PostFormValue("user_id"))
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "invalid_request")
+		return
+	}
+
+	impersonation, accessToken, err := auth.Impersonate(db, session, auth.UserID(userID), time.Now().Add(impersonationLifetime))
+	switch errors.Cause(err) {
+	case nil:
+	case auth.ErrForbidden:
+		writeError(w, http.StatusForbidden, "access_denied")
+		return
+	case auth.ErrNotFound:
+		writeError(w, http.StatusBadRequest, "invalid_request")
+		return
+	default:
+		writeServerError(w, err)
+		return
+	}
+
+	fmt.Printf("staff %d impersonating user %d, session %d\n", session.UserID, userID, impersonation.ID)
+	writeJSON(w, http.StatusOK, tokenResponse{
+		AccessToken: accessToken.Token,
+		TokenType:   "Bearer",
+		ExpiresIn:   int64(impersonationLifetime / time.Second),
+	})
+}
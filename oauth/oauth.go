@@ -0,0 +1,152 @@
+package oauth
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/thematthopkins/impact-go/auth"
+)
+
+const (
+	accessTokenLifetime  = time.Hour
+	refreshTokenLifetime = 30 * 24 * time.Hour
+)
+
+type tokenResponse struct {
+	AccessToken  string `json:"access_token"`
+	TokenType    string `json:"token_type"`
+	ExpiresIn    int64  `json:"expires_in"`
+	RefreshToken string `json:"refresh_token,omitempty"`
+	Scope        string `json:"scope,omitempty"`
+}
+
+type errorResponse struct {
+	Error string `json:"error"`
+}
+
+// Token is the OAuth2 token endpoint, supporting the refresh_token and client_credentials grants
+func Token(w http.ResponseWriter, r *http.Request, db *sql.DB) {
+	if r.Method != http.MethodPost {
+		w.Header().Set("Allow", http.MethodPost)
+		http.Error(w, "Method Not Allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	switch r.PostFormValue("grant_type") {
+	case "refresh_token":
+		refreshTokenGrant(w, r, db)
+	case "client_credentials":
+		clientCredentialsGrant(w, r, db)
+	default:
+		writeError(w, http.StatusBadRequest, "unsupported_grant_type")
+	}
+}
+
+func refreshTokenGrant(w http.ResponseWriter, r *http.Request, db *sql.DB) {
+	refreshToken := r.PostFormValue("refresh_token")
+	if refreshToken == "" {
+		writeError(w, http.StatusBadRequest, "invalid_request")
+		return
+	}
+
+	now := time.Now()
+	accessToken, newRefreshToken, err := auth.RefreshSessionToken(db, refreshToken, now.Add(accessTokenLifetime), now.Add(refreshTokenLifetime))
+	switch errors.Cause(err) {
+	case nil:
+	case auth.ErrRefreshTokenReused:
+		fmt.Println("refresh token reused, session revoked: ", err)
+		writeError(w, http.StatusBadRequest, "invalid_grant")
+		return
+	case auth.ErrRefreshTokenInvalid:
+		writeError(w, http.StatusBadRequest, "invalid_grant")
+		return
+	default:
+		writeServerError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, tokenResponse{
+		AccessToken:  accessToken.Token,
+		TokenType:    "Bearer",
+		ExpiresIn:    int64(accessTokenLifetime / time.Second),
+		RefreshToken: newRefreshToken.Token,
+	})
+}
+
+// clientCredentialsGrant authenticates the client with HTTP Basic auth or the
+// client_id and client_secret form values
+func clientCredentialsGrant(w http.ResponseWriter, r *http.Request, db *sql.DB) {
+	clientID, secret, basicAuth := r.BasicAuth()
+	if !basicAuth {
+		clientID = r.PostFormValue("client_id")
+		secret = r.PostFormValue("client_secret")
+	}
+	if clientID == "" || secret == "" {
+		writeError(w, http.StatusBadRequest, "invalid_request")
+		return
+	}
+
+	scopes := []auth.Scope{}
+	for _, scope := range strings.Fields(r.PostFormValue("scope")) {
+		scopes = append(scopes, auth.Scope(scope))
+	}
+
+	principal, accessToken, err := auth.AddClientSession(db, auth.ClientID(clientID), secret, scopes, time.Now().Add(accessTokenLifetime))
+	switch errors.Cause(err) {
+	case nil:
+	case auth.ErrClientInvalid:
+		if basicAuth {
+			w.Header().Set("WWW-Authenticate", `Basic realm="oauth"`)
+		}
+		writeError(w, http.StatusUnauthorized, "invalid_client")
+		return
+	case auth.ErrScopeInvalid:
+		writeError(w, http.StatusBadRequest, "invalid_scope")
+		return
+	default:
+		writeServerError(w, err)
+		return
+	}
+
+	grantedScopes := make([]string, len(principal.Scopes))
+	for i, scope := range principal.Scopes {
+		grantedScopes[i] = string(scope)
+	}
+
+	writeJSON(w, http.StatusOK, tokenResponse{
+		AccessToken: accessToken.Token,
+		TokenType:   "Bearer",
+		ExpiresIn:   int64(accessTokenLifetime / time.Second),
+		Scope:       strings.Join(grantedScopes, " "),
+	})
+}
+
+// writeServerError reports a failure not caused by the request, as 503 when the database is unavailable
+func writeServerError(w http.ResponseWriter, err error) {
+	fmt.Println("oauth error: ", err)
+	if errors.Cause(err) == auth.ErrDBUnavailable {
+		writeError(w, http.StatusServiceUnavailable, "temporarily_unavailable")
+		return
+	}
+	writeError(w, http.StatusInternalServerError, "server_error")
+}
+
+func writeError(w http.ResponseWriter, status int, code string) {
+	writeJSON(w, status, errorResponse{Error: code})
+}
+
+func writeJSON(w http.ResponseWriter, status int, body interface{}) {
+	w.Header().Set("Content-Type", "application/json;charset=UTF-8")
+	w.Header().Set("Cache-Control", "no-store")
+	w.Header().Set("Pragma", "no-cache")
+	w.WriteHeader(status)
+	err := json.NewEncoder(w).Encode(body)
+	if err != nil {
+		fmt.Println("error writing response: ", err)
+	}
+}
@@ -0,0 +1,148 @@
+package oauth_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/thematthopkins/impact-go/auth"
+	"github.com/thematthopkins/impact-go/oauth"
+	"github.com/thematthopkins/impact-go/testdb"
+)
+
+func postForm(path string, form url.Values) *http.Request {
+	request := httptest.NewRequest("POST", path, strings.NewReader(form.Encode()))
+	request.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	return request
+}
+
+func TestTokenMethodNotAllowed(t *testing.T) {
+	db := testdb.Setup()
+	response := httptest.NewRecorder()
+	oauth.Token(response, httptest.NewRequest("GET", "/oauth/token", nil), db)
+
+	assert.Equal(t, http.StatusMethodNotAllowed, response.Code)
+}
+
+func TestTokenUnsupportedGrant(t *testing.T) {
+	db := testdb.Setup()
+	response := httptest.NewRecorder()
+	oauth.Token(response, postForm("/oauth/token", url.Values{"grant_type": {"password"}}), db)
+
+	assert.Equal(t, http.StatusBadRequest, response.Code)
+	assert.JSONEq(t, `{"error": "unsupported_grant_type"}`, response.Body.String())
+}
+
+func TestTokenRefresh(t *testing.T) {
+	db := testdb.Setup()
+	sessionID, err := auth.AddSession(db, "impact.development", 1234)
+	assert.NoError(t, err)
+	_, refreshToken, err := auth.AddSessionToken(db, sessionID, time.Now().Add(time.Minute), time.Now().Add(time.Minute))
+	assert.NoError(t, err)
+
+	response := httptest.NewRecorder()
+	oauth.Token(response, postForm("/oauth/token", url.Values{
+		"grant_type":    {"refresh_token"},
+		"refresh_token": {refreshToken.Token},
+	}), db)
+
+	assert.Equal(t, http.StatusOK, response.Code)
+	assert.Contains(t, response.Body.String(), `"token_type":"Bearer"`)
+
+	response = httptest.NewRecorder()
+	oauth.Token(response, postForm("/oauth/token", url.Values{
+		"grant_type":    {"refresh_token"},
+		"refresh_token": {refreshToken.Token},
+	}), db)
+
+	assert.Equal(t, http.StatusBadRequest, response.Code)
+	assert.JSONEq(t, `{"error": "invalid_grant"}`, response.Body.String())
+}
+
+func TestRevokeMissingToken(t *testing.T) {
+	db := testdb.Setup()
+	response := httptest.NewRecorder()
+	oauth.Revoke(response, postForm("/oauth/revoke", url.Values{}), db)
+
+	assert.Equal(t, http.StatusBadRequest, response.Code)
+	assert.JSONEq(t, `{"error": "invalid_request"}`, response.Body.String())
+}
+
+func TestRevoke(t *testing.T) {
+	db := testdb.Setup()
+	sessionID, err := auth.AddSession(db, "impact.development", 1234)
+	assert.NoError(t, err)
+	accessToken, _, err := auth.AddSessionToken(db, sessionID, time.Now().Add(time.Minute), time.Now().Add(time.Minute))
+	assert.NoError(t, err)
+
+	response := httptest.NewRecorder()
+	oauth.Revoke(response, postForm("/oauth/revoke", url.Values{"token": {accessToken.Token}}), db)
+	assert.Equal(t, http.StatusOK, response.Code)
+
+	response = httptest.NewRecorder()
+	oauth.Revoke(response, postForm("/oauth/revoke", url.Values{"token": {accessToken.Token}}), db)
+	assert.Equal(t, http.StatusOK, response.Code)
+}
+
+func TestLogout(t *testing.T) {
+	db := testdb.Setup()
+	sessionID, err := auth.AddSession(db, "impact.development", 1234)
+	assert.NoError(t, err)
+
+	response := httptest.NewRecorder()
+	oauth.Logout(response, postForm("/oauth/logout", url.Values{}), db, auth.Session{ID: sessionID, Principal: auth.Principal{OwnerType: auth.UserOwner, UserID: 1234}})
+	assert.Equal(t, http.StatusNoContent, response.Code)
+}
+
+func TestTokenClientCredentialsMissingClient(t *testing.T) {
+	db := testdb.Setup()
+	response := httptest.NewRecorder()
+	oauth.Token(response, postForm("/oauth/token", url.Values{"grant_type": {"client_credentials"}}), db)
+
+	assert.Equal(t, http.StatusBadRequest, response.Code)
+	assert.JSONEq(t, `{"error": "invalid_request"}`, response.Body.String())
+}
+
+func TestTokenClientCredentialsInvalidClient(t *testing.T) {
+	db := testdb.Setup()
+	request := postForm("/oauth/token", url.Values{"grant_type": {"client_credentials"}})
+	request.SetBasicAuth("unknown-client", "secret")
+	response := httptest.NewRecorder()
+	oauth.Token(response, request, db)
+
+	assert.Equal(t, http.StatusUnauthorized, response.Code)
+	assert.NotEmpty(t, response.Header().Get("WWW-Authenticate"))
+	assert.JSONEq(t, `{"error": "invalid_client"}`, response.Body.String())
+}
+
+func TestImpersonateInvalidUser(t *testing.T) {
+	db := testdb.Setup()
+	response := httptest.NewRecorder()
+	staff := auth.Session{ID: 1, Principal: auth.Principal{OwnerType: auth.UserOwner, UserID: 1}}
+	oauth.Impersonate(response, postForm("/oauth/impersonate", url.Values{"user_id": {"me"}}), db, staff)
+
+	assert.Equal(t, http.StatusBadRequest, response.Code)
+	assert.JSONEq(t, `{"error": "invalid_request"}`, response.Body.String())
+}
+
+func TestImpersonateWhileImpersonating(t *testing.T) {
+	db := testdb.Setup()
+	response := httptest.NewRecorder()
+	impersonated := auth.Session{ID: 1, Principal: auth.Principal{OwnerType: auth.UserOwner, UserID: 1234, ImpersonatorID: 1}}
+	oauth.Impersonate(response, postForm("/oauth/impersonate", url.Values{"user_id": {"2"}}), db, impersonated)
+
+	assert.Equal(t, http.StatusForbidden, response.Code)
+}
+
+func TestLogoutAllWhileImpersonating(t *testing.T) {
+	db := testdb.Setup()
+	response := httptest.NewRecorder()
+	impersonated := auth.Session{ID: 1, Principal: auth.Principal{OwnerType: auth.UserOwner, UserID: 1234, ImpersonatorID: 1}}
+	oauth.LogoutAll(response, postForm("/oauth/logout/all", url.Values{}), db, impersonated)
+
+	assert.Equal(t, http.StatusForbidden, response.Code)
+}
@@ -0,0 +1,71 @@
+package oauth
+
+import (
+	"database/sql"
+	"net/http"
+
+	"github.com/thematthopkins/impact-go/auth"
+)
+
+// Revoke is the RFC 7009 token revocation endpoint, accepting either an access
+// or refresh token.  Responds 200 whether or not the token was valid.
+func Revoke(w http.ResponseWriter, r *http.Request, db *sql.DB) {
+	if r.Method != http.MethodPost {
+		w.Header().Set("Allow", http.MethodPost)
+		http.Error(w, "Method Not Allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	token := r.PostFormValue("token")
+	if token == "" {
+		writeError(w, http.StatusBadRequest, "invalid_request")
+		return
+	}
+
+	err := auth.RevokeToken(db, token)
+	if err != nil {
+		writeServerError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+}
+
+// Logout revokes the session of the request's access token
+func Logout(w http.ResponseWriter, r *http.Request, db *sql.DB, session auth.Session) {
+	if r.Method != http.MethodPost {
+		w.Header().Set("Allow", http.MethodPost)
+		http.Error(w, "Method Not Allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	err := auth.RevokeSession(db, session.ID)
+	if err != nil {
+		writeServerError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// LogoutAll revokes every session of the request's user
+func LogoutAll(w http.ResponseWriter, r *http.Request, db *sql.DB, session auth.Session) {
+	if r.Method != http.MethodPost {
+		w.Header().Set("Allow", http.MethodPost)
+		http.Error(w, "Method Not Allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	if !session.IsUser() || !session.Permits(auth.ChangeCredentials) {
+		http.Error(w, "Forbidden", http.StatusForbidden)
+		return
+	}
+
+	err := auth.RevokeUserSessions(db, session.UserID)
+	if err != nil {
+		writeServerError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -3,15 +3,153 @@ package routes
 import (
 	"database/sql"
 	"fmt"
+	"io"
+	"io/ioutil"
+	"math"
+	"mime"
+	"mime/multipart"
 	"net/http"
+	"net/http/httputil"
+	"net/url"
+	"os"
+	"strconv"
+	"time"
 
+	"github.com/pkg/errors"
 	"github.com/thematthopkins/impact-go/auth"
+	"github.com/thematthopkins/impact-go/oauth"
+	"github.com/thematthopkins/impact-go/sessions"
 	"github.com/thematthopkins/impact-go/verificationreport"
 )
 
 // Handle adds golang handled routes to the http multiplexer
 func Handle(mux *http.ServeMux, db *sql.DB) {
-	handleFuncAuthenticated("/report", verificationreport.Export, db)
+	handleFuncAuthorized("/report", auth.ExportReport, verificationreport.Export, db)
+	handleFuncWithDB("/oauth/token", oauth.Token, db)
+	handleFuncWithDB("/oauth/revoke", oauth.Revoke, db)
+	handleFuncWithSession("/oauth/logout", oauth.Logout, db)
+	handleFuncWithSession("/oauth/logout/all", oauth.LogoutAll, db)
+	handleFuncWithSession("/oauth/impersonate", oauth.Impersonate, db)
+	handleFuncWithSession("/sessions", sessions.List, db)
+	handleFuncWithSession("/sessions/", sessions.Revoke, db)
+}
+
+// HandleProxy forwards every route not handled in go to the PHP api at phpURL.  Bearer tokens
+// are validated here first and forwarded as their stored id, which is all the PHP api can
+// look tokens up by, and requests passing tokens any other way refused.  Requests of impersonated sessions are audited, and as the restricted
+// actions can't be told apart among the PHP routes, limited to reading.
+func HandleProxy(phpURL *url.URL, db *sql.DB) {
+	proxy := httputil.NewSingleHostReverseProxy(phpURL)
+	http.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		body, err := spoolForm(r)
+		if err != nil {
+			fmt.Println("error reading request: ", err)
+			http.Error(w, "Server Error", http.StatusInternalServerError)
+			return
+		}
+		if body != nil {
+			defer os.Remove(body.Name())
+			defer body.Close()
+			r.Body = body
+		}
+		if found, err := hasTokenParameter(r, body); found || err != nil {
+			http.Error(w, "Bad Request", http.StatusBadRequest)
+			return
+		}
+
+		if _, ok := auth.BearerToken(r); !ok {
+			proxy.ServeHTTP(w, r)
+			return
+		}
+		withSession(w, r, db, func(session auth.Session) {
+			if session.IsImpersonated() && !safeMethod(r.Method) {
+				http.Error(w, "Forbidden", http.StatusForbidden)
+				return
+			}
+			r.Header.Set("Authorization", "Bearer "+session.AccessTokenID)
+			proxy.ServeHTTP(w, r)
+		})
+	})
+}
+
+// tokenParameter the query and form parameter the PHP api also accepts access tokens as.  Only
+// the Authorization header is validated here, so requests carrying one are refused.
+const tokenParameter = "access_token"
+
+// maxFormBytes limits the url encoded form read for tokenParameter, as http.Request.ParseForm does
+const maxFormBytes = 10 << 20
+
+// spoolForm copies the body of a form request to a temporary file the caller removes, so it
+// can be searched for tokenParameter and still be forwarded.  nil for other requests.
+func spoolForm(r *http.Request) (*os.File, error) {
+	mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	if mediaType != "application/x-www-form-urlencoded" && mediaType != "multipart/form-data" {
+		return nil, nil
+	}
+
+	body, err := ioutil.TempFile("", "proxy-form")
+	if err != nil {
+		return nil, errors.Wrap(err, "error creating form spool")
+	}
+	_, err = io.Copy(body, r.Body)
+	if err == nil {
+		_, err = body.Seek(0, io.SeekStart)
+	}
+	if err != nil {
+		body.Close()
+		os.Remove(body.Name())
+		return nil, errors.Wrap(err, "error spooling form")
+	}
+	return body, nil
+}
+
+// hasTokenParameter if the query of r, or its spooled form body, has tokenParameter.  body is
+// rewound afterwards, a malformed form body returning an error.
+func hasTokenParameter(r *http.Request, body *os.File) (bool, error) {
+	if _, ok := r.URL.Query()[tokenParameter]; ok {
+		return true, nil
+	}
+	if body == nil {
+		return false, nil
+	}
+	defer body.Seek(0, io.SeekStart)
+
+	mediaType, params, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	if mediaType == "multipart/form-data" {
+		reader := multipart.NewReader(body, params["boundary"])
+		for {
+			part, err := reader.NextPart()
+			if err == io.EOF {
+				return false, nil
+			} else if err != nil {
+				return false, errors.Wrap(err, "error reading multipart form")
+			}
+			if part.FormName() == tokenParameter {
+				return true, nil
+			}
+		}
+	}
+
+	data, err := ioutil.ReadAll(io.LimitReader(body, maxFormBytes+1))
+	if err != nil {
+		return false, errors.Wrap(err, "error reading form")
+	}
+	if len(data) > maxFormBytes {
+		return false, errors.New("form too large")
+	}
+	values, err := url.ParseQuery(string(data))
+	_, ok := values[tokenParameter]
+	return ok, err
+}
+
+// safeMethod if requests with method only read
+func safeMethod(method string) bool {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions:
+		return true
+	default:
+		return false
+	}
 }
 
 func handleFuncWithPanicRecovery(path string, fn func(http.ResponseWriter, *http.Request)) {
@@ -26,16 +164,116 @@ func handleFuncWithPanicRecovery(path string, fn func(http.ResponseWriter, *http
 	})
 }
 
-func handleFuncAuthenticated(path string, fn func(http.ResponseWriter, *http.Request, *sql.DB, auth.UserID), db *sql.DB) {
+func handleFuncWithDB(path string, fn func(http.ResponseWriter, *http.Request, *sql.DB), db *sql.DB) {
+	handleFuncWithPanicRecovery(path, func(w http.ResponseWriter, r *http.Request) {
+		fn(w, r, db)
+	})
+}
+
+func handleFuncWithSession(path string, fn func(http.ResponseWriter, *http.Request, *sql.DB, auth.Session), db *sql.DB) {
 	handleFuncWithPanicRecovery(path, func(w http.ResponseWriter, r *http.Request) {
-		userID, err := auth.Validate(r, db)
-		if err == auth.ErrSessionInvalid {
+		withSession(w, r, db, func(session auth.Session) {
+			fn(w, r, db, session)
+		})
+	})
+}
+
+// withSession calls fn with the validated session of r, responding with the error otherwise
+func withSession(w http.ResponseWriter, r *http.Request, db *sql.DB, fn func(auth.Session)) {
+	if backingOff(w, r) {
+		return
+	}
+	session, err := auth.ValidateSession(r, db)
+	if cause := errors.Cause(err); cause == auth.ErrSessionInvalid {
+		if auth.IsSessionExpired(err) || !rateLimited(w, r) {
 			http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		}
+	} else if cause == auth.ErrNoAuthHeader {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+	} else if cause == auth.ErrDBUnavailable {
+		fmt.Println("error authenticating: ", err)
+		http.Error(w, "Service Unavailable", http.StatusServiceUnavailable)
+	} else if err != nil {
+		fmt.Println("error authenticating: ", err)
+		http.Error(w, "Server Error", http.StatusInternalServerError)
+	} else if session.IsImpersonated() && !auditImpersonation(w, r, db, session) {
+		return
+	} else {
+		fn(session)
+	}
+}
+
+// backingOff responds 429 while the sender of r must wait after presenting too many invalid
+// tokens, before its token is validated, so even a valid one is refused.  When the limiter's
+// store is unavailable the request isn't limited.
+func backingOff(w http.ResponseWriter, r *http.Request) bool {
+	token, ok := auth.BearerToken(r)
+	if !ok {
+		return false
+	}
+	wait, err := auth.TokenLimiter.RetryAfter(r, token)
+	if err != nil {
+		fmt.Println("error checking failed attempts: ", err)
+		return false
+	}
+	return tooManyRequests(w, wait)
+}
+
+// rateLimited records the token of r as a failed attempt, responding 429 if its sender has
+// presented too many.  When the limiter's store is unavailable the failure isn't counted.
+func rateLimited(w http.ResponseWriter, r *http.Request) bool {
+	token, _ := auth.BearerToken(r)
+	wait, err := auth.TokenLimiter.Failed(r, token)
+	if err != nil {
+		fmt.Println("error recording failed attempt: ", err)
+		return false
+	}
+	return tooManyRequests(w, wait)
+}
+
+// tooManyRequests responds 429 with Retry-After when there's a wait
+func tooManyRequests(w http.ResponseWriter, wait time.Duration) bool {
+	if wait <= 0 {
+		return false
+	}
+
+	w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(wait.Seconds()))))
+	http.Error(w, "Too Many Requests", http.StatusTooManyRequests)
+	return true
+}
+
+// auditImpersonation logs the request before it's served, refusing it if the log can't be written
+func auditImpersonation(w http.ResponseWriter, r *http.Request, db *sql.DB, session auth.Session) bool {
+	err := auth.AuditImpersonation(db, session, r.Method, r.URL.Path)
+	if err != nil {
+		fmt.Println("error auditing impersonation: ", err)
+		http.Error(w, "Service Unavailable", http.StatusServiceUnavailable)
+		return false
+	}
+	return true
+}
+
+// handleFuncAuthorized requires permission for action on the assessment of the assessmentId query parameter
+func handleFuncAuthorized(path string, action auth.Action, fn func(http.ResponseWriter, *http.Request, *sql.DB, auth.UserID, auth.AssessmentID), db *sql.DB) {
+	handleFuncWithSession(path, func(w http.ResponseWriter, r *http.Request, db *sql.DB, session auth.Session) {
+		id, err := strconv.ParseInt(r.URL.Query().Get("assessmentId"), 10, 64)
+		if err != nil {
+			http.Error(w, "Bad Request", http.StatusBadRequest)
+			return
+		}
+		assessmentID := auth.AssessmentID(id)
+
+		err = auth.Authorize(db, session.Principal, assessmentID, action)
+		if cause := errors.Cause(err); cause == auth.ErrForbidden {
+			http.Error(w, "Forbidden", http.StatusForbidden)
+		} else if cause == auth.ErrDBUnavailable {
+			fmt.Println("error authorizing: ", err)
+			http.Error(w, "Service Unavailable", http.StatusServiceUnavailable)
 		} else if err != nil {
-			fmt.Println("error authenticating: ", err)
+			fmt.Println("error authorizing: ", err)
 			http.Error(w, "Server Error", http.StatusInternalServerError)
 		} else {
-			fn(w, r, db, userID)
+			fn(w, r, db, session.UserID, assessmentID)
 		}
-	})
+	}, db)
 }
@@ -0,0 +1,115 @@
+package routes_test
+
+import (
+	"bytes"
+	"io/ioutil"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/thematthopkins/impact-go/auth"
+	"github.com/thematthopkins/impact-go/routes"
+)
+
+// forwarded is the body of each request reaching the PHP api
+var forwarded []string
+
+func TestMain(m *testing.M) {
+	php := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := ioutil.ReadAll(r.Body)
+		forwarded = append(forwarded, string(body))
+	}))
+	phpURL, _ := url.Parse(php.URL)
+	routes.HandleProxy(phpURL, nil)
+
+	code := m.Run()
+	php.Close()
+	os.Exit(code)
+}
+
+func serve(r *http.Request) *httptest.ResponseRecorder {
+	forwarded = nil
+	w := httptest.NewRecorder()
+	http.DefaultServeMux.ServeHTTP(w, r)
+	return w
+}
+
+func TestHandleProxy_ForwardsForms(t *testing.T) {
+	r := httptest.NewRequest("POST", "/api/answers", strings.NewReader("answer=yes"))
+	r.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	w := serve(r)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, []string{"answer=yes"}, forwarded)
+}
+
+func TestHandleProxy_RefusesTokenQuery(t *testing.T) {
+	w := serve(httptest.NewRequest("GET", "/api/assessments?access_token=stored-id", nil))
+
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Empty(t, forwarded)
+}
+
+func TestHandleProxy_RefusesTokenForm(t *testing.T) {
+	r := httptest.NewRequest("POST", "/api/answers", strings.NewReader("answer=yes&access_token=stored-id"))
+	r.Header.Set("Content-Type", "application/x-www-form-urlencoded; charset=utf-8")
+
+	w := serve(r)
+
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Empty(t, forwarded)
+}
+
+func TestHandleProxy_RefusesTokenMultipartForm(t *testing.T) {
+	var body bytes.Buffer
+	form := multipart.NewWriter(&body)
+	file, _ := form.CreateFormFile("evidence", "evidence.pdf")
+	file.Write([]byte("%PDF-1.4"))
+	form.WriteField("access_token", "stored-id")
+	form.Close()
+
+	r := httptest.NewRequest("POST", "/api/evidence", &body)
+	r.Header.Set("Content-Type", form.FormDataContentType())
+
+	w := serve(r)
+
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Empty(t, forwarded)
+}
+
+func TestHandleProxy_RefusesValidTokenWhileBackingOff(t *testing.T) {
+	limiter := auth.TokenLimiter
+	defer func() { auth.TokenLimiter = limiter }()
+	auth.TokenLimiter = auth.NewLimiter(auth.NewMemoryAttemptStore(100), 0, time.Minute, time.Minute, time.Hour)
+
+	defer auth.ValidationCache.Clear()
+	auth.ValidationCache.Add("valid-token", auth.Session{ID: 1, Principal: auth.Principal{OwnerType: auth.UserOwner, UserID: 1234}}, time.Now().Add(time.Minute))
+
+	request := func(remoteAddr string) *http.Request {
+		r := httptest.NewRequest("GET", "/api/assessments", nil)
+		r.RemoteAddr = remoteAddr
+		r.Header.Set("Authorization", "Bearer valid-token")
+		return r
+	}
+
+	guess := httptest.NewRequest("GET", "/api/assessments", nil)
+	guess.RemoteAddr = "10.0.0.2:1234"
+	_, err := auth.TokenLimiter.Failed(guess, "guessed-token")
+	assert.NoError(t, err)
+
+	w := serve(request("10.0.0.2:1234"))
+	assert.Equal(t, http.StatusTooManyRequests, w.Code)
+	assert.Equal(t, "60", w.Header().Get("Retry-After"))
+	assert.Empty(t, forwarded)
+
+	w = serve(request("10.0.0.1:1234"))
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Len(t, forwarded, 1)
+}
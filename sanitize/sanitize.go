@@ -1,12 +1,60 @@
 package sanitize
 
-import "database/sql"
+import (
+	"database/sql"
+
+	"github.com/thematthopkins/impact-go/contingency"
+)
 
 //AssessmentID of a question in the db
 type AssessmentID int
 
 type responseID int
 
+//Store of the responses to an assessment's questions
+type Store interface {
+	//Archive the responses, keeping them for reference but removing them from the assessment
+	Archive(assessmentID AssessmentID, questions []contingency.QuestionSfid) error
+	//Clear the responses
+	Clear(assessmentID AssessmentID, questions []contingency.QuestionSfid) error
+	//SetHiddenByContingency excludes the responses from scoring and reports while hidden, or counts them again
+	SetHiddenByContingency(assessmentID AssessmentID, questions []contingency.QuestionSfid, hidden bool) error
+}
+
+//ApplyCascade takes the actions of plan on the responses to assessmentID.
+//Every hidden response is excluded from scoring and reports, then archived or cleared as planned.
+func ApplyCascade(store Store, assessmentID AssessmentID, plan contingency.CascadePlan) error {
+	hidden := make([]contingency.QuestionSfid, 0, len(plan.Hidden))
+	for _, cascade := range plan.Hidden {
+		hidden = append(hidden, cascade.Question)
+	}
+	if len(hidden) > 0 {
+		err := store.SetHiddenByContingency(assessmentID, hidden, true)
+		if err != nil {
+			return err
+		}
+	}
+
+	if archived := plan.Questions(contingency.Archive); len(archived) > 0 {
+		err := store.Archive(assessmentID, archived)
+		if err != nil {
+			return err
+		}
+	}
+
+	if cleared := plan.Questions(contingency.Clear); len(cleared) > 0 {
+		err := store.Clear(assessmentID, cleared)
+		if err != nil {
+			return err
+		}
+	}
+
+	if len(plan.Shown) > 0 {
+		return store.SetHiddenByContingency(assessmentID, plan.Shown, false)
+	}
+	return nil
+}
+
 func sanitize(assessmentID AssessmentID, db *sql.DB) {
 	// rows, err := db.Query(
 	// 	// Ensure the name and age parameters only match on placeholder name, not position.
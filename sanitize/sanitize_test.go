@@ -1,10 +1,12 @@
 package sanitize
 
 import (
+	"fmt"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/thematthopkins/impact-go/auth"
+	"github.com/thematthopkins/impact-go/contingency"
 	"github.com/thematthopkins/impact-go/testdb"
 )
 
@@ -13,3 +15,55 @@ func TestInvalidClientName(t *testing.T) {
 	_, err := auth.AddSession(db, "invalidClientID", 1234)
 	assert.Error(t, err, "failed to find oauth client: invalidClientID")
 }
+
+type recordingStore struct {
+	calls []string
+}
+
+func (s *recordingStore) Archive(assessmentID AssessmentID, questions []contingency.QuestionSfid) error {
+	s.calls = append(s.calls, fmt.Sprintf("archive %d %v", assessmentID, questions))
+	return nil
+}
+
+func (s *recordingStore) Clear(assessmentID AssessmentID, questions []contingency.QuestionSfid) error {
+	s.calls = append(s.calls, fmt.Sprintf("clear %d %v", assessmentID, questions))
+	return nil
+}
+
+func (s *recordingStore) SetHiddenByContingency(assessmentID AssessmentID, questions []contingency.QuestionSfid, hidden bool) error {
+	s.calls = append(s.calls, fmt.Sprintf("hidden %d %v %v", assessmentID, questions, hidden))
+	return nil
+}
+
+func TestApplyCascade(t *testing.T) {
+	store := &recordingStore{}
+
+	err := ApplyCascade(store, 7, contingency.CascadePlan{
+		Hidden: []contingency.Cascade{
+			{Question: "q1", Action: contingency.RetainExcluded},
+			{Question: "q2", Action: contingency.Archive},
+			{Question: "q3", Action: contingency.Clear},
+		},
+		Shown: []contingency.QuestionSfid{"q4"},
+	})
+
+	assert.NoError(t, err)
+	assert.Equal(t,
+		[]string{
+			"hidden 7 [q1 q2 q3] true",
+			"archive 7 [q2]",
+			"clear 7 [q3]",
+			"hidden 7 [q4] false",
+		},
+		store.calls,
+	)
+}
+
+func TestApplyCascade_Empty(t *testing.T) {
+	store := &recordingStore{}
+
+	err := ApplyCascade(store, 7, contingency.CascadePlan{})
+
+	assert.NoError(t, err)
+	assert.Empty(t, store.calls)
+}
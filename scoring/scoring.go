@@ -1,5 +1,7 @@
 package scoring
 
+import "github.com/thematthopkins/impact-go/contingency"
+
 // Response is user input
 type Response struct {
 	IsAnswered          bool
@@ -78,3 +80,42 @@ func score(
 ) float64 {
 	return clamp(unclampedPerformance, 0, 1) * worth
 }
+
+// Points earned by response, responses hidden by a contingency are excluded
+// from scoring and earn none
+func Points(
+	response Response,
+	standard Standard,
+) float64 {
+	if response.HiddenByContingency {
+		return 0
+	}
+	return score(unclampedPerformance(response, standard), standard.Worth)
+}
+
+// ApplyCascade marks the responses hidden by plan as HiddenByContingency, whatever
+// their action, and counts the responses it shows again
+func ApplyCascade(
+	responses map[contingency.QuestionSfid]Response,
+	plan contingency.CascadePlan,
+) {
+	for _, cascade := range plan.Hidden {
+		setHiddenByContingency(responses, cascade.Question, true)
+	}
+	for _, question := range plan.Shown {
+		setHiddenByContingency(responses, question, false)
+	}
+}
+
+func setHiddenByContingency(
+	responses map[contingency.QuestionSfid]Response,
+	question contingency.QuestionSfid,
+	hidden bool,
+) {
+	response, ok := responses[question]
+	if !ok {
+		return
+	}
+	response.HiddenByContingency = hidden
+	responses[question] = response
+}
@@ -4,6 +4,7 @@ import (
 	"testing"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/thematthopkins/impact-go/contingency"
 )
 
 func TestUnclampedPerformance_StraightPercentage(t *testing.T) {
@@ -82,3 +83,39 @@ func TestScore(t *testing.T) {
 	assert.Equal(t, 0.0, score(-100, 25))
 	assert.Equal(t, 10.0, score(0.1, 100))
 }
+
+func TestPoints(t *testing.T) {
+	standard := Standard{
+		ScoringMethod: StraightPercentage,
+		Worth:         10,
+	}
+
+	assert.Equal(t, 5.0, Points(Response{PercentResponse: 50}, standard))
+	assert.Equal(t, 0.0, Points(Response{PercentResponse: 50, HiddenByContingency: true}, standard))
+}
+
+func TestApplyCascade(t *testing.T) {
+	responses := map[contingency.QuestionSfid]Response{
+		"q1": {PercentResponse: 50},
+		"q2": {PercentResponse: 50},
+		"q3": {PercentResponse: 50, HiddenByContingency: true},
+	}
+
+	ApplyCascade(responses, contingency.CascadePlan{
+		Hidden: []contingency.Cascade{
+			{Question: "q1", Action: contingency.RetainExcluded},
+			{Question: "q2", Action: contingency.Archive},
+			{Question: "q4", Action: contingency.Clear},
+		},
+		Shown: []contingency.QuestionSfid{"q3"},
+	})
+
+	assert.Equal(t,
+		map[contingency.QuestionSfid]Response{
+			"q1": {PercentResponse: 50, HiddenByContingency: true},
+			"q2": {PercentResponse: 50, HiddenByContingency: true},
+			"q3": {PercentResponse: 50},
+		},
+		responses,
+	)
+}
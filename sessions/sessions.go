@@ -0,0 +1,102 @@
+package sessions
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/thematthopkins/impact-go/auth"
+)
+
+type sessionResponse struct {
+	ID         auth.SessionID `json:"id"`
+	ClientName string         `json:"clientName"`
+	CreatedAt  time.Time      `json:"createdAt"`
+	LastSeenAt *time.Time     `json:"lastSeenAt"`
+	IP         string         `json:"ip"`
+	UserAgent  string         `json:"userAgent"`
+	Current    bool           `json:"current"`
+}
+
+// List responds with the active sessions of the session's user
+func List(w http.ResponseWriter, r *http.Request, db *sql.DB, session auth.Session) {
+	if r.Method != http.MethodGet {
+		w.Header().Set("Allow", http.MethodGet)
+		http.Error(w, "Method Not Allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	if !session.IsUser() {
+		http.Error(w, "Forbidden", http.StatusForbidden)
+		return
+	}
+
+	sessions, err := auth.ListSessions(db, session.UserID)
+	if err != nil {
+		writeServerError(w, err)
+		return
+	}
+
+	response := make([]sessionResponse, len(sessions))
+	for i, s := range sessions {
+		response[i] = sessionResponse{
+			ID:         s.ID,
+			ClientName: string(s.ClientName),
+			CreatedAt:  s.CreatedAt,
+			LastSeenAt: s.LastSeenAt,
+			IP:         s.LastIP,
+			UserAgent:  s.LastUserAgent,
+			Current:    s.ID == session.ID,
+		}
+	}
+
+	w.Header().Set("Content-Type", "application/json;charset=UTF-8")
+	w.Header().Set("Cache-Control", "no-store")
+	err = json.NewEncoder(w).Encode(response)
+	if err != nil {
+		fmt.Println("error writing response: ", err)
+	}
+}
+
+// Revoke the user's session identified by the path suffix, as in DELETE /sessions/123
+func Revoke(w http.ResponseWriter, r *http.Request, db *sql.DB, session auth.Session) {
+	if r.Method != http.MethodDelete {
+		w.Header().Set("Allow", http.MethodDelete)
+		http.Error(w, "Method Not Allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	if !session.IsUser() || !session.Permits(auth.ChangeCredentials) {
+		http.Error(w, "Forbidden", http.StatusForbidden)
+		return
+	}
+
+	id, err := strconv.ParseInt(r.URL.Path[strings.LastIndex(r.URL.Path, "/")+1:], 10, 64)
+	if err != nil {
+		http.Error(w, "Not Found", http.StatusNotFound)
+		return
+	}
+
+	err = auth.RevokeUserSession(db, session.UserID, auth.SessionID(id))
+	if errors.Cause(err) == auth.ErrNotFound {
+		http.Error(w, "Not Found", http.StatusNotFound)
+		return
+	} else if err != nil {
+		writeServerError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func writeServerError(w http.ResponseWriter, err error) {
+	fmt.Println("sessions error: ", err)
+	if errors.Cause(err) == auth.ErrDBUnavailable {
+		http.Error(w, "Service Unavailable", http.StatusServiceUnavailable)
+		return
+	}
+	http.Error(w, "Server Error", http.StatusInternalServerError)
+}
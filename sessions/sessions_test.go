@@ -0,0 +1,65 @@
+package sessions_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/thematthopkins/impact-go/auth"
+	"github.com/thematthopkins/impact-go/sessions"
+	"github.com/thematthopkins/impact-go/testdb"
+)
+
+func userSession(id auth.SessionID) auth.Session {
+	return auth.Session{ID: id, Principal: auth.Principal{OwnerType: auth.UserOwner, UserID: 1234}}
+}
+
+func TestListClientForbidden(t *testing.T) {
+	db := testdb.Setup()
+	response := httptest.NewRecorder()
+	client := auth.Session{ID: 1, Principal: auth.Principal{OwnerType: auth.ClientOwner, ClientID: "partner"}}
+	sessions.List(response, httptest.NewRequest("GET", "/sessions", nil), db, client)
+
+	assert.Equal(t, http.StatusForbidden, response.Code)
+}
+
+func TestRevokeInvalidID(t *testing.T) {
+	db := testdb.Setup()
+	response := httptest.NewRecorder()
+	sessions.Revoke(response, httptest.NewRequest("DELETE", "/sessions/abc", nil), db, userSession(1))
+
+	assert.Equal(t, http.StatusNotFound, response.Code)
+}
+
+func TestRevokeWhileImpersonating(t *testing.T) {
+	db := testdb.Setup()
+	response := httptest.NewRecorder()
+	impersonated := auth.Session{ID: 1, Principal: auth.Principal{OwnerType: auth.UserOwner, UserID: 1234, ImpersonatorID: 1}}
+	sessions.Revoke(response, httptest.NewRequest("DELETE", "/sessions/2", nil), db, impersonated)
+
+	assert.Equal(t, http.StatusForbidden, response.Code)
+}
+
+func TestListAndRevoke(t *testing.T) {
+	db := testdb.Setup()
+	current, _, _, err := auth.AddSessionWithToken(db, "impact.development", 1234, time.Now().Add(time.Minute), time.Now().Add(time.Hour))
+	assert.NoError(t, err)
+	other, _, _, err := auth.AddSessionWithToken(db, "impact.development", 1234, time.Now().Add(time.Minute), time.Now().Add(time.Hour))
+	assert.NoError(t, err)
+
+	response := httptest.NewRecorder()
+	sessions.List(response, httptest.NewRequest("GET", "/sessions", nil), db, userSession(current))
+	assert.Equal(t, http.StatusOK, response.Code)
+	assert.Contains(t, response.Body.String(), `"current":true`)
+
+	response = httptest.NewRecorder()
+	sessions.Revoke(response, httptest.NewRequest("DELETE", "/sessions/"+strconv.FormatInt(int64(other), 10), nil), db, userSession(current))
+	assert.Equal(t, http.StatusNoContent, response.Code)
+
+	response = httptest.NewRecorder()
+	sessions.Revoke(response, httptest.NewRequest("DELETE", "/sessions/"+strconv.FormatInt(int64(other), 10), nil), db, userSession(current))
+	assert.Equal(t, http.StatusNotFound, response.Code)
+}
@@ -7,6 +7,6 @@ import (
 	"net/http"
 )
 
-func Export(w http.ResponseWriter, r *http.Request, db *sql.DB, id auth.UserID) {
-	fmt.Fprintf(w, "Verification Report %v", id)
+func Export(w http.ResponseWriter, r *http.Request, db *sql.DB, id auth.UserID, assessmentID auth.AssessmentID) {
+	fmt.Fprintf(w, "Verification Report %v %v", id, assessmentID)
 }